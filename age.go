@@ -0,0 +1,47 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"time"
+)
+
+// CreatedAt returns the time a value was first stored for a given request,
+// to the second, and whether the request is registered.
+func (reg *Registry) CreatedAt(r *http.Request) (time.Time, bool) {
+	reg.rlock()
+	defer reg.mutex.RUnlock()
+	t, ok := reg.datat[reg.resolve(r)]
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(t, 0), true
+}
+
+// Age returns the time since a value was first stored for a given request,
+// or 0 if the request isn't registered.
+func (reg *Registry) Age(r *http.Request) time.Duration {
+	reg.rlock()
+	defer reg.mutex.RUnlock()
+	r = reg.resolve(r)
+	t, ok := reg.datat[r]
+	if !ok {
+		return 0
+	}
+	return reg.now(r).Sub(time.Unix(t, 0))
+}
+
+// CreatedAt returns the time a value was first stored for a given request,
+// to the second, and whether the request is registered.
+func CreatedAt(r *http.Request) (time.Time, bool) {
+	return defaultRegistry("CreatedAt").CreatedAt(r)
+}
+
+// Age returns the time since a value was first stored for a given request,
+// or 0 if the request isn't registered.
+func Age(r *http.Request) time.Duration {
+	return defaultRegistry("Age").Age(r)
+}
@@ -0,0 +1,37 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCreatedAt(t *testing.T) {
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+
+	if _, ok := CreatedAt(r); ok {
+		t.Error("Unregistered request has a creation time")
+	}
+	if age := Age(r); age != 0 {
+		t.Errorf("Expected no age, got %v.", age)
+	}
+
+	before := time.Now().Truncate(time.Second)
+	Set(r, key1, "1")
+	created, ok := CreatedAt(r)
+	if !ok || created.Before(before) || created.After(time.Now()) {
+		t.Errorf("Unexpected creation time %v.", created)
+	}
+
+	builtin.mutex.Lock()
+	builtin.datat[r] -= 60
+	builtin.mutex.Unlock()
+	if age := Age(r); age < time.Minute || age > 2*time.Minute {
+		t.Errorf("Unexpected age %v.", age)
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package baggage propagates request values using the W3C Baggage header
+// (https://www.w3.org/TR/baggage/).
+//
+// Handler stores the members of the inbound baggage header as request
+// values, and Header serializes them back for outgoing requests:
+//
+//	http.Handle("/", context.ClearHandler(baggage.Handler(h)))
+//
+//	func h(w http.ResponseWriter, r *http.Request) {
+//		tenant, _ := baggage.Get(r, "tenant")
+//		out, _ := http.NewRequest("GET", url, nil)
+//		out.Header.Set(baggage.HeaderName, baggage.Header(r, "tenant"))
+//		// ...
+//	}
+package baggage
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/context"
+)
+
+// HeaderName is the name of the baggage header.
+const HeaderName = "Baggage"
+
+// Limits set by the specification.
+const (
+	maxMembers = 180
+	maxBytes   = 8192
+)
+
+// key is the type of the keys baggage values are stored under, so that
+// they can't collide with other request values.
+type key string
+
+// Parse parses the value of a baggage header. Members that can't be parsed
+// are skipped, as are member properties.
+func Parse(header string) map[string]string {
+	members := make(map[string]string)
+	for _, member := range strings.Split(header, ",") {
+		if i := strings.IndexByte(member, ';'); i >= 0 {
+			member = member[:i]
+		}
+		i := strings.IndexByte(member, '=')
+		if i < 0 {
+			continue
+		}
+		name := strings.TrimSpace(member[:i])
+		value, err := url.PathUnescape(strings.TrimSpace(member[i+1:]))
+		if name == "" || err != nil {
+			continue
+		}
+		members[name] = value
+		if len(members) == maxMembers {
+			break
+		}
+	}
+	return members
+}
+
+// Handler wraps an http.Handler and stores the members of the baggage
+// header of a request before calling it.
+func Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, header := range r.Header.Values(HeaderName) {
+			for name, value := range Parse(header) {
+				Set(r, name, value)
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// Set stores a baggage member for a given request.
+func Set(r *http.Request, name, value string) {
+	context.Set(r, key(name), value)
+}
+
+// Get returns a baggage member stored for a given request.
+func Get(r *http.Request, name string) (string, bool) {
+	value, ok := context.GetOk(r, key(name))
+	s, _ := value.(string)
+	return s, ok
+}
+
+// Header returns the value of a baggage header holding the given members
+// of a request, or all its members if no name is given. Members are
+// sorted by name, and dropped past the limits of the specification.
+func Header(r *http.Request, names ...string) string {
+	values := make(map[string]string)
+	if len(names) == 0 {
+		for k, v := range context.GetAll(r) {
+			if k, ok := k.(key); ok {
+				values[string(k)] = v.(string)
+			}
+		}
+	} else {
+		for _, name := range names {
+			if value, ok := Get(r, name); ok {
+				values[name] = value
+			}
+		}
+	}
+	sorted := make([]string, 0, len(values))
+	for name := range values {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	var b strings.Builder
+	for i, name := range sorted {
+		if i == maxMembers {
+			break
+		}
+		member := name + "=" + escape(values[name])
+		if b.Len()+len(member)+1 > maxBytes {
+			break
+		}
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(member)
+	}
+	return b.String()
+}
+
+// escape percent-encodes the characters not allowed in baggage values.
+func escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c > 0x20 && c < 0x7f && c != '"' && c != ',' && c != ';' && c != '\\' && c != '%' {
+			b.WriteByte(c)
+		} else {
+			b.WriteByte('%')
+			b.WriteByte("0123456789ABCDEF"[c>>4])
+			b.WriteByte("0123456789ABCDEF"[c&0xf])
+		}
+	}
+	return b.String()
+}
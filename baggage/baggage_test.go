@@ -0,0 +1,63 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package baggage
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gorilla/context"
+)
+
+func TestParse(t *testing.T) {
+	got := Parse("userId=alice, serverNode = DF%2028 ;prop, isProduction=false,invalid,=empty")
+	want := map[string]string{
+		"userId":       "alice",
+		"serverNode":   "DF 28",
+		"isProduction": "false",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v.", want, got)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	r := httptest.NewRequest("GET", "http://localhost:8080/", nil)
+	defer context.Clear(r)
+	r.Header.Set(HeaderName, "tenant=acme,user=bob")
+
+	var tenant string
+	Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant, _ = Get(r, "tenant")
+	})).ServeHTTP(httptest.NewRecorder(), r)
+
+	if tenant != "acme" {
+		t.Errorf("Expected acme, got %q.", tenant)
+	}
+	// Baggage doesn't collide with other values.
+	if context.Get(r, "tenant") != nil {
+		t.Error("Baggage stored under a plain string key")
+	}
+}
+
+func TestHeader(t *testing.T) {
+	r := httptest.NewRequest("GET", "http://localhost:8080/", nil)
+	defer context.Clear(r)
+	context.Set(r, "other", "value")
+	Set(r, "user", "bob")
+	Set(r, "node", "DF 28,a;b")
+
+	if h := Header(r); h != "node=DF%2028%2Ca%3Bb,user=bob" {
+		t.Errorf("Unexpected header %q.", h)
+	}
+	if h := Header(r, "user", "missing"); h != "user=bob" {
+		t.Errorf("Unexpected header %q.", h)
+	}
+	if got := Parse(Header(r)); got["node"] != "DF 28,a;b" {
+		t.Errorf("Header doesn't round trip: %v.", got)
+	}
+}
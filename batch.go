@@ -0,0 +1,87 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// SetMulti stores values in a given request, taking the lock once. It
+// behaves like calling Set for each value, except that nothing is stored
+// if any of the writes is refused, see Freeze and MarkImmutable.
+func (reg *Registry) SetMulti(r *http.Request, values map[interface{}]interface{}) {
+	atomic.AddUint64(&reg.counters.sets, uint64(len(values)))
+	reg.lock()
+	r = reg.resolve(r)
+	checked := make(map[interface{}]interface{}, len(values))
+	for k, v := range values {
+		v, err := reg.checkSet(r, k, v)
+		if err != nil {
+			reg.unlock()
+			reg.refuse(r, err)
+			return
+		}
+		checked[k] = v
+	}
+	values = checked
+	bad := reg.usedAfterClear(r)
+	for k, v := range values {
+		reg.set(r, k, v)
+	}
+	task := reg.tracing(r)
+	reg.unlock()
+	for k, v := range values {
+		if bad {
+			reg.reportUseAfterClear(r, "SetMulti", k)
+		}
+		task.log(k, v)
+	}
+}
+
+// GetMulti returns the values stored for the given keys in a given
+// request, taking the lock once. Keys with no value, and no global default
+// set with SetGlobalDefault, are missing from the result.
+func (reg *Registry) GetMulti(r *http.Request, keys ...interface{}) map[interface{}]interface{} {
+	atomic.AddUint64(&reg.counters.gets, uint64(len(keys)))
+	for _, k := range keys {
+		reg.countKey(k, true)
+	}
+	result := make(map[interface{}]interface{}, len(keys))
+	reg.rlock()
+	r = reg.resolve(r)
+	reg.touch(r)
+	ctx := reg.data[r]
+	for _, k := range keys {
+		if v, ok := ctx[k]; ok && !reg.expired(r, k) {
+			result[k] = v
+		}
+	}
+	reg.mutex.RUnlock()
+	forceAll(result)
+	for _, k := range keys {
+		if _, ok := result[k]; ok {
+			continue
+		}
+		if v, ok := reg.globalDefault(k); ok {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// SetMulti stores values in a given request, taking the lock once. It
+// behaves like calling Set for each value, except that nothing is stored
+// if any of the writes is refused, see Freeze and MarkImmutable.
+func SetMulti(r *http.Request, values map[interface{}]interface{}) {
+	defaultRegistry("SetMulti").SetMulti(r, values)
+}
+
+// GetMulti returns the values stored for the given keys in a given
+// request, taking the lock once. Keys with no value, and no global default
+// set with SetGlobalDefault, are missing from the result.
+func GetMulti(r *http.Request, keys ...interface{}) map[interface{}]interface{} {
+	return defaultRegistry("GetMulti").GetMulti(r, keys...)
+}
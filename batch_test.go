@@ -0,0 +1,51 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestSetMulti(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		t.Helper()
+		if !reflect.DeepEqual(val, exp) {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+
+	SetMulti(r, map[interface{}]interface{}{key1: "1", key2: "2"})
+	assertEqual(Get(r, key1), "1")
+	assertEqual(Get(r, key2), "2")
+
+	assertEqual(GetMulti(r, key1, key2, "missing"), map[interface{}]interface{}{key1: "1", key2: "2"})
+	assertEqual(GetMulti(r), map[interface{}]interface{}{})
+
+	SetLazy(r, "lazy", func() interface{} { return "computed" })
+	assertEqual(GetMulti(r, "lazy"), map[interface{}]interface{}{"lazy": "computed"})
+}
+
+func TestSetMultiRefused(t *testing.T) {
+	reg := New()
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer reg.Clear(r)
+
+	var refused error
+	reg.HandleRefusedWrites(func(r *http.Request, err error) { refused = err })
+	reg.Set(r, key1, "1")
+	reg.Freeze(r)
+	reg.SetMulti(r, map[interface{}]interface{}{key2: "2"})
+	if refused != ErrFrozen {
+		t.Errorf("Expected ErrFrozen, got %v.", refused)
+	}
+	if _, ok := reg.GetOk(r, key2); ok {
+		t.Errorf("Unexpected value for a frozen request.")
+	}
+}
@@ -0,0 +1,266 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package clearcheck defines an Analyzer reporting servers that store
+// request values with gorilla/context but never clear them.
+//
+// Values stored with context.Set are kept until the request is cleared,
+// usually by wrapping the top-level handler with context.ClearHandler.
+// Programs that forget to do so leak the values of every request. The
+// analyzer reports the calls starting a server, such as
+// http.ListenAndServe, in programs that store values but never reference
+// ClearHandler, ClearHandlerWithOptions, Clear, ClearAll or
+// ClearOnShutdown, in the package starting the server or in its
+// dependencies.
+//
+// It can be run with go vet:
+//
+//	go install github.com/gorilla/context/clearcheck/cmd/clearcheck
+//	go vet -vettool=$(which clearcheck) ./...
+package clearcheck
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+const contextPath = "github.com/gorilla/context"
+
+// Analyzer reports servers that store request values without clearing
+// them.
+var Analyzer = &analysis.Analyzer{
+	Name:      "clearcheck",
+	Doc:       "report servers storing gorilla/context values without clearing them",
+	Run:       run,
+	FactTypes: []analysis.Fact{new(usage)},
+}
+
+// usage is the package fact recording whether a package or its
+// dependencies store and clear request values.
+type usage struct {
+	Sets   bool
+	Clears bool
+}
+
+func (*usage) AFact() {}
+
+func (u *usage) String() string {
+	switch {
+	case u.Sets && u.Clears:
+		return "sets and clears"
+	case u.Sets:
+		return "sets"
+	case u.Clears:
+		return "clears"
+	}
+	return "none"
+}
+
+var (
+	// setters are the functions and the Key and Namespace methods
+	// registering a request, which keeps it until Clear.
+	setters = map[string]bool{
+		"Add":               true,
+		"AddError":          true,
+		"AddFlash":          true,
+		"AppendValue":       true,
+		"ConnHandler":       true,
+		"Copy":              true,
+		"Do":                true,
+		"ExtractHeaders":    true,
+		"Freeze":            true,
+		"GetOrCompute":      true,
+		"Handle":            true,
+		"Link":              true,
+		"Memoize":           true,
+		"Merge":             true,
+		"NewNamespace":      true,
+		"OnClear":           true,
+		"ProxyDirector":     true,
+		"ProxyHandler":      true,
+		"PushScope":         true,
+		"RequestIDHandler":  true,
+		"Resolve":           true,
+		"ResponseHandler":   true,
+		"Restore":           true,
+		"Retain":            true,
+		"Set":               true,
+		"SetChecked":        true,
+		"SetClock":          true,
+		"SetCurrentRoute":   true,
+		"SetFuture":         true,
+		"SetIfAbsent":       true,
+		"SetLazy":           true,
+		"SetLogger":         true,
+		"SetMulti":          true,
+		"SetOnce":           true,
+		"SetPrincipal":      true,
+		"SetResponseHeader": true,
+		"SetVars":           true,
+		"SetWithTTL":        true,
+		"Swap":              true,
+		"TraceHandler":      true,
+		"Update":            true,
+		"Watch":             true,
+	}
+	// clearers are the functions clearing values.
+	clearers = map[string]bool{
+		"Clear":                   true,
+		"ClearAll":                true,
+		"ClearHandler":            true,
+		"ClearHandlerWithOptions": true,
+		"ClearOnShutdown":         true,
+	}
+	// servers are the net/http functions and *http.Server methods
+	// starting a server.
+	servers = map[string]bool{
+		"ListenAndServe":    true,
+		"ListenAndServeTLS": true,
+		"Serve":             true,
+		"ServeTLS":          true,
+	}
+)
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	if pass.Pkg.Path() == contextPath {
+		// Its own calls would make every program look like it clears.
+		return nil, nil
+	}
+	u := new(usage)
+	for _, imp := range pass.Pkg.Imports() {
+		var dep usage
+		if pass.ImportPackageFact(imp, &dep) {
+			u.Sets = u.Sets || dep.Sets
+			u.Clears = u.Clears || dep.Clears
+		}
+	}
+
+	var starts []*ast.CallExpr
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.Ident:
+				// Clear functions may be referenced without being
+				// called, e.g. passed to a middleware chain.
+				if fn, ok := pass.TypesInfo.Uses[n].(*types.Func); ok && isClearer(fn) {
+					u.Clears = true
+				}
+			case *ast.CallExpr:
+				fn := callee(pass.TypesInfo, n)
+				switch {
+				case fn == nil:
+				case isSetter(fn):
+					u.Sets = true
+				case isServerStart(fn):
+					starts = append(starts, n)
+				}
+			}
+			return true
+		})
+	}
+
+	if u.Sets && !u.Clears {
+		for _, call := range starts {
+			pass.Reportf(call.Pos(), "server stores gorilla/context values but never clears them: wrap the handler with context.ClearHandler")
+		}
+	}
+	if u.Sets || u.Clears {
+		pass.ExportPackageFact(u)
+	}
+	return nil, nil
+}
+
+// callee returns the function or method called by call, if any.
+func callee(info *types.Info, call *ast.CallExpr) *types.Func {
+	var id *ast.Ident
+	switch fun := unparen(call.Fun).(type) {
+	case *ast.Ident:
+		id = fun
+	case *ast.SelectorExpr:
+		id = fun.Sel
+	default:
+		return nil
+	}
+	fn, _ := info.Uses[id].(*types.Func)
+	return fn
+}
+
+// unparen returns e with its parentheses removed, like ast.Unparen, which
+// requires Go 1.22.
+func unparen(e ast.Expr) ast.Expr {
+	for {
+		p, ok := e.(*ast.ParenExpr)
+		if !ok {
+			return e
+		}
+		e = p.X
+	}
+}
+
+// isSetter reports whether fn registers a request in a registry: a
+// setter of gorilla/context using the default registry, a Namespace method
+// storing values, or (*Registry).Namespace.
+func isSetter(fn *types.Func) bool {
+	switch recv, ok := contextRecv(fn); {
+	case !ok:
+		return false
+	case recv == "Registry":
+		return fn.Name() == "Namespace"
+	case recv == "Namespace":
+		return fn.Name() == "Set"
+	}
+	return setters[fn.Name()]
+}
+
+// isClearer reports whether fn clears values in the default registry.
+// Namespace.Clear only clears its namespace.
+func isClearer(fn *types.Func) bool {
+	recv, ok := contextRecv(fn)
+	return ok && (recv == "" || recv == "Key") && clearers[fn.Name()]
+}
+
+// contextRecv reports whether fn is a package-level function of
+// gorilla/context, or a method of its Key, Namespace or Registry types,
+// and returns the name of its receiver type, if any.
+func contextRecv(fn *types.Func) (string, bool) {
+	if fn.Pkg() == nil || fn.Pkg().Path() != contextPath {
+		return "", false
+	}
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return "", true
+	}
+	t := recv.Type()
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok {
+		return "", false
+	}
+	switch name := named.Obj().Name(); name {
+	case "Key", "Namespace", "Registry":
+		return name, true
+	}
+	return "", false
+}
+
+// isServerStart reports whether fn starts a net/http server.
+func isServerStart(fn *types.Func) bool {
+	if fn.Pkg() == nil || fn.Pkg().Path() != "net/http" || !servers[fn.Name()] {
+		return false
+	}
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return true
+	}
+	ptr, ok := recv.Type().(*types.Pointer)
+	if !ok {
+		return false
+	}
+	named, ok := ptr.Elem().(*types.Named)
+	return ok && named.Obj().Name() == "Server"
+}
@@ -0,0 +1,16 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package clearcheck
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "leaky", "clean", "wrapped", "keyed", "ids",
+		"headers", "director", "proxied", "namespaced", "registry", "nsset", "clearall", "shutdown")
+}
@@ -0,0 +1,16 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command clearcheck runs the clearcheck analyzer, reporting servers that
+// store gorilla/context values without clearing them.
+package main
+
+import (
+	"github.com/gorilla/context/clearcheck"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(clearcheck.Analyzer)
+}
@@ -0,0 +1,10 @@
+package clean
+
+import (
+	"net/http"
+)
+
+// Servers not storing values aren't reported.
+func main() {
+	http.ListenAndServe(":8080", nil)
+}
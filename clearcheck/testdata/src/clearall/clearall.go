@@ -0,0 +1,15 @@
+package clearall // want package:"sets and clears"
+
+import (
+	"net/http"
+
+	"github.com/gorilla/context"
+)
+
+func main() {
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		context.Set(r, "user", "alice")
+	})
+	defer context.ClearAll()
+	http.ListenAndServe(":8080", nil)
+}
@@ -0,0 +1,13 @@
+package director // want package:"sets"
+
+import (
+	"net/http"
+	"net/http/httputil"
+
+	"github.com/gorilla/context"
+)
+
+func main() {
+	proxy := &httputil.ReverseProxy{Director: context.ProxyDirector(func(*http.Request) {})}
+	http.ListenAndServe(":8080", proxy) // want "server stores gorilla/context values but never clears them"
+}
@@ -0,0 +1,44 @@
+// Package context is a stub of gorilla/context for the analyzer tests.
+package context
+
+import "net/http"
+
+func Set(r *http.Request, key, val interface{}) {}
+
+func Update(r *http.Request, key interface{}, fn func(old interface{}) interface{}) {}
+
+func Clear(r *http.Request) {}
+
+func ClearHandler(h http.Handler) http.Handler { return h }
+
+func RequestIDHandler(h http.Handler) http.Handler { return h }
+
+type Key[T any] struct{}
+
+func NewKey[T any](name string) *Key[T] { return new(Key[T]) }
+
+func (k *Key[T]) Set(r *http.Request, val T) {}
+
+func ClearAll() int { return 0 }
+
+func ClearOnShutdown(srv *http.Server) {}
+
+func ExtractHeaders(next http.Handler, mapping map[string]interface{}) http.Handler { return next }
+
+func ProxyHandler(h http.Handler) http.Handler { return h }
+
+func ProxyDirector(next func(*http.Request), keys ...interface{}) func(*http.Request) { return next }
+
+type Registry struct{}
+
+func New() *Registry { return new(Registry) }
+
+func (reg *Registry) Namespace(r *http.Request, name string) *Namespace { return new(Namespace) }
+
+type Namespace struct{}
+
+func NewNamespace(r *http.Request, name string) *Namespace { return new(Namespace) }
+
+func (ns *Namespace) Set(key, val interface{}) {}
+
+func (ns *Namespace) Clear() {}
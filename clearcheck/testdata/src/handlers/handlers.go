@@ -0,0 +1,11 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/context"
+)
+
+func Index(w http.ResponseWriter, r *http.Request) {
+	context.Set(r, "user", "alice")
+}
@@ -0,0 +1,12 @@
+package headers // want package:"sets"
+
+import (
+	"net/http"
+
+	"github.com/gorilla/context"
+)
+
+func main() {
+	h := context.ExtractHeaders(http.NotFoundHandler(), map[string]interface{}{"X-User": "user"})
+	http.ListenAndServe(":8080", h) // want "server stores gorilla/context values but never clears them"
+}
@@ -0,0 +1,12 @@
+package ids // want package:"sets"
+
+import (
+	"net/http"
+
+	"github.com/gorilla/context"
+)
+
+// Middleware storing values registers the requests too.
+func main() {
+	http.ListenAndServe(":8080", context.RequestIDHandler(http.NotFoundHandler())) // want "server stores gorilla/context values but never clears them"
+}
@@ -0,0 +1,17 @@
+package keyed // want package:"sets"
+
+import (
+	"net/http"
+
+	"github.com/gorilla/context"
+)
+
+var userKey = context.NewKey[string]("user")
+
+func main() {
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		userKey.Set(r, "alice")
+		context.Update(r, "visits", func(old interface{}) interface{} { return 1 })
+	})
+	http.ListenAndServe(":8080", nil) // want "server stores gorilla/context values but never clears them"
+}
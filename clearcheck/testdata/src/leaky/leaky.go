@@ -0,0 +1,15 @@
+package leaky // want package:"sets"
+
+import (
+	"net/http"
+
+	"handlers"
+)
+
+func main() {
+	http.HandleFunc("/", handlers.Index)
+	http.ListenAndServe(":8080", nil) // want "server stores gorilla/context values but never clears them"
+
+	srv := &http.Server{Addr: ":8080"}
+	srv.ListenAndServe() // want "server stores gorilla/context values but never clears them"
+}
@@ -0,0 +1,14 @@
+package namespaced // want package:"sets"
+
+import (
+	"net/http"
+
+	"github.com/gorilla/context"
+)
+
+func main() {
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		context.NewNamespace(r, "auth")
+	})
+	http.ListenAndServe(":8080", nil) // want "server stores gorilla/context values but never clears them"
+}
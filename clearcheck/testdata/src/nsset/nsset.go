@@ -0,0 +1,17 @@
+package nsset // want package:"sets"
+
+import (
+	"net/http"
+
+	"github.com/gorilla/context"
+)
+
+// Clearing a namespace doesn't clear the request.
+func store(ns *context.Namespace) {
+	ns.Set("user", "alice")
+	ns.Clear()
+}
+
+func main() {
+	http.ListenAndServe(":8080", nil) // want "server stores gorilla/context values but never clears them"
+}
@@ -0,0 +1,11 @@
+package proxied // want package:"sets"
+
+import (
+	"net/http"
+
+	"github.com/gorilla/context"
+)
+
+func main() {
+	http.ListenAndServe(":8080", context.ProxyHandler(http.NotFoundHandler())) // want "server stores gorilla/context values but never clears them"
+}
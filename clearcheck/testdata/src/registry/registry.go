@@ -0,0 +1,16 @@
+package registry // want package:"sets"
+
+import (
+	"net/http"
+
+	"github.com/gorilla/context"
+)
+
+var reg = context.New()
+
+func main() {
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		reg.Namespace(r, "auth")
+	})
+	http.ListenAndServe(":8080", nil) // want "server stores gorilla/context values but never clears them"
+}
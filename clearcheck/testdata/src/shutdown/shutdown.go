@@ -0,0 +1,16 @@
+package shutdown // want package:"sets and clears"
+
+import (
+	"net/http"
+
+	"github.com/gorilla/context"
+)
+
+func main() {
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		context.Set(r, "user", "alice")
+	})
+	srv := &http.Server{Addr: ":8080"}
+	context.ClearOnShutdown(srv)
+	srv.ListenAndServe()
+}
@@ -0,0 +1,13 @@
+package wrapped // want package:"sets and clears"
+
+import (
+	"net/http"
+
+	"github.com/gorilla/context"
+	"handlers"
+)
+
+func main() {
+	h := http.HandlerFunc(handlers.Index)
+	http.ListenAndServe(":8080", context.ClearHandler(h))
+}
@@ -0,0 +1,88 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Clock tells the time. It's used for the creation and access times of
+// requests, the expiration of the values stored with SetWithTTL, and
+// purging, so that tests can control time instead of sleeping.
+type Clock interface {
+	Now() time.Time
+}
+
+// clockHolder wraps a Clock, for atomic.Pointer.
+type clockHolder struct {
+	Clock
+}
+
+// defaultClock holds the clock set with SetDefaultClock.
+var defaultClock atomic.Pointer[clockHolder]
+
+// SetDefaultClock sets the clock used for the requests with no clock set
+// with SetClock, in all registries and stores. Passing nil restores the
+// system clock. It's meant for tests.
+func SetDefaultClock(c Clock) {
+	if c == nil {
+		defaultClock.Store(nil)
+		return
+	}
+	defaultClock.Store(&clockHolder{c})
+}
+
+// now returns the time on the default clock.
+func now() time.Time {
+	if h := defaultClock.Load(); h != nil {
+		return h.Now()
+	}
+	return time.Now()
+}
+
+// SetClock sets the clock used for a given request, registering it if
+// needed. The creation time of a request is taken when it's registered,
+// so SetClock is best called before storing values.
+//
+// Purge and the janitor compare the age of the request, on its clock, to
+// their limit: advancing the clock of a request ages it.
+func (reg *Registry) SetClock(r *http.Request, c Clock) {
+	reg.lock()
+	r = reg.resolve(r)
+	if c == nil {
+		delete(reg.clocks, r)
+	} else {
+		if reg.clocks == nil {
+			reg.clocks = make(map[*http.Request]Clock)
+		}
+		reg.clocks[r] = c
+		if !reg.register(r) {
+			delete(reg.clocks, r)
+		}
+	}
+	reg.publish(r)
+	reg.unlock()
+}
+
+// SetClock sets the clock used for a given request, registering it if
+// needed. The creation time of a request is taken when it's registered,
+// so SetClock is best called before storing values.
+//
+// Purge and the janitor compare the age of the request, on its clock, to
+// their limit: advancing the clock of a request ages it.
+func SetClock(r *http.Request, c Clock) {
+	defaultRegistry("SetClock").SetClock(r, c)
+}
+
+// now returns the time on the clock of a given resolved request. It must
+// be called with the lock held, for reading at least.
+func (reg *Registry) now(r *http.Request) time.Time {
+	if c := reg.clocks[r]; c != nil {
+		return c.Now()
+	}
+	return now()
+}
@@ -0,0 +1,76 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mutex.Lock()
+	c.now = c.now.Add(d)
+	c.mutex.Unlock()
+}
+
+func TestSetClock(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		t.Helper()
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	reg := New()
+	c := &fakeClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	other, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	reg.SetClock(r, c)
+	reg.SetWithTTL(r, key1, "1", time.Minute)
+	reg.Set(other, key1, "1")
+
+	created, _ := reg.CreatedAt(r)
+	assertEqual(created, c.now.Local())
+	assertEqual(reg.Get(r, key1), "1")
+
+	c.advance(2 * time.Minute)
+	assertEqual(reg.Get(r, key1), nil)
+	assertEqual(reg.Age(r), 2*time.Minute)
+
+	// Purge ages the request on its own clock.
+	assertEqual(reg.Purge(60), 1)
+	assertEqual(reg.Get(other, key1), "1")
+	reg.Clear(other)
+}
+
+func TestSetDefaultClock(t *testing.T) {
+	c := &fakeClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	SetDefaultClock(c)
+	defer SetDefaultClock(nil)
+
+	reg := New()
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	reg.Set(r, key1, "1")
+	if n := reg.Purge(3600); n != 0 {
+		t.Errorf("Expected no purged request, got %d.", n)
+	}
+	c.advance(2 * time.Hour)
+	if n := reg.Purge(3600); n != 1 {
+		t.Errorf("Expected 1 purged request, got %d.", n)
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+)
+
+// Cloner returns a KeyOption making GetAllDeep copy the values of a key
+// with fn, such as func(v interface{}) interface{} { return
+// v.(*Session).Clone() }.
+func Cloner(fn func(interface{}) interface{}) KeyOption {
+	return func(info *keyInfo) {
+		info.cloner = fn
+	}
+}
+
+// GetAllDeep is like GetAll, but copies the values, so that the map can
+// be handed to a background goroutine while the handler keeps modifying
+// the values, such as slices or structs stored by pointer. The values of
+// the keys registered with Cloner are copied with their own cloner, and
+// the other ones with cloner. A nil cloner leaves them as they are.
+//
+// The cloners run without the registry locked.
+func (reg *Registry) GetAllDeep(r *http.Request, cloner func(interface{}) interface{}) map[interface{}]interface{} {
+	values := reg.GetAll(r)
+	cloneAll(values, cloner)
+	return values
+}
+
+// GetAllDeep is like GetAll, but copies the values, so that the map can
+// be handed to a background goroutine while the handler keeps modifying
+// the values, such as slices or structs stored by pointer. The values of
+// the keys registered with Cloner are copied with their own cloner, and
+// the other ones with cloner. A nil cloner leaves them as they are.
+func GetAllDeep(r *http.Request, cloner func(interface{}) interface{}) map[interface{}]interface{} {
+	values := DefaultStore().GetAll(r)
+	cloneAll(values, cloner)
+	return values
+}
+
+// cloneAll replaces the values of m with their copies.
+func cloneAll(m map[interface{}]interface{}, cloner func(interface{}) interface{}) {
+	for k, v := range m {
+		fn := cloner
+		if info := lookupKey(k); info != nil && info.cloner != nil {
+			fn = info.cloner
+		}
+		if fn != nil {
+			m[k] = fn(v)
+		}
+	}
+}
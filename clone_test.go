@@ -0,0 +1,66 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+type cloneKey int
+
+const (
+	cloneSliceKey cloneKey = iota
+	cloneMapKey
+)
+
+func TestGetAllDeep(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		t.Helper()
+		if !reflect.DeepEqual(val, exp) {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	RegisterKey(cloneMapKey, Cloner(func(v interface{}) interface{} {
+		c := make(map[string]int)
+		for k, n := range v.(map[string]int) {
+			c[k] = n
+		}
+		return c
+	}))
+
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+	assertEqual(GetAllDeep(r, nil), map[interface{}]interface{}(nil))
+
+	s := []string{"a"}
+	m := map[string]int{"a": 1}
+	Set(r, cloneSliceKey, s)
+	Set(r, cloneMapKey, m)
+	Set(r, key1, "1")
+
+	values := GetAllDeep(r, func(v interface{}) interface{} {
+		if s, ok := v.([]string); ok {
+			return append([]string(nil), s...)
+		}
+		return v
+	})
+	s[0] = "b"
+	m["a"] = 2
+	assertEqual(values, map[interface{}]interface{}{
+		cloneSliceKey: []string{"a"},
+		cloneMapKey:   map[string]int{"a": 1},
+		key1:          "1",
+	})
+
+	// Without a cloner, only the keys registered with Cloner are copied.
+	values = GetAllDeep(r, nil)
+	s[0] = "c"
+	m["a"] = 3
+	assertEqual(values[cloneSliceKey], []string{"c"})
+	assertEqual(values[cloneMapKey], map[string]int{"a": 2})
+}
@@ -0,0 +1,111 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	gocontext "context"
+	"net"
+	"net/http"
+)
+
+// conns holds the values stored with ConnSet.
+var conns = NewScope[net.Conn]()
+
+// connKey is the key the connection of a request is stored under in its
+// context.
+type connKey struct{}
+
+// ConfigureServer configures srv so that the connection of its requests is
+// available through Conn, and the values stored for a connection with
+// ConnSet are cleared when it's closed. Existing ConnContext and ConnState
+// functions of srv are still called.
+func ConfigureServer(srv *http.Server) {
+	connContext, connState := srv.ConnContext, srv.ConnState
+	srv.ConnContext = func(ctx gocontext.Context, c net.Conn) gocontext.Context {
+		if connContext != nil {
+			ctx = connContext(ctx, c)
+		}
+		return gocontext.WithValue(ctx, connKey{}, c)
+	}
+	srv.ConnState = func(c net.Conn, state http.ConnState) {
+		if connState != nil {
+			connState(c, state)
+		}
+		if state == http.StateClosed {
+			ConnClear(c)
+		}
+	}
+}
+
+// Conn returns the connection a request was received on. It requires the
+// server to be configured with ConfigureServer.
+func Conn(r *http.Request) (net.Conn, bool) {
+	c, ok := r.Context().Value(connKey{}).(net.Conn)
+	return c, ok
+}
+
+// ConnSet stores a value for a given key in a given connection, such as the
+// identity of a TLS client or a rate limiter shared by its requests.
+func ConnSet(c net.Conn, key, val interface{}) {
+	conns.Set(c, key, val)
+}
+
+// ConnGet returns a value stored for a given key in a given connection.
+func ConnGet(c net.Conn, key interface{}) interface{} {
+	return conns.Get(c, key)
+}
+
+// ConnClear removes all values stored for a given connection. Servers
+// configured with ConfigureServer call it when connections are closed.
+func ConnClear(c net.Conn) {
+	conns.Clear(c)
+}
+
+// TransferToConn moves the values stored for a given request to a given
+// connection, typically the one obtained by hijacking the request, so that
+// they outlive the request:
+//
+//	conn, _, err := w.(http.Hijacker).Hijack()
+//	// ...
+//	context.TransferToConn(r, conn)
+//	defer context.ConnClear(conn)
+//	user := context.ConnGet(conn, userKey)
+//
+// The servers don't report hijacked connections as closed, so ConnClear
+// must be called once the connection is done with. The OnClear functions
+// of the request are not transferred, and the values stored with SetLazy
+// are moved without being computed. If the request is frozen or holds
+// immutable values, the values are copied but stay in the request until
+// it's cleared.
+func TransferToConn(r *http.Request, c net.Conn) {
+	reg := defaultRegistry("TransferToConn")
+	for k, v := range reg.rawValues(r) {
+		conns.Set(c, k, v)
+	}
+	reg.tryClearExcept(r)
+}
+
+// ConnHandler wraps an http.Handler and stores in each request the values
+// of the given keys stored for its connection, or all of them when no key
+// is given, before calling it. It requires the server to be configured
+// with ConfigureServer.
+func ConnHandler(h http.Handler, keys ...interface{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c, ok := Conn(r); ok {
+			values := conns.GetAll(c)
+			if len(keys) == 0 {
+				for k, v := range values {
+					Set(r, k, v)
+				}
+			}
+			for _, k := range keys {
+				if v, ok := values[k]; ok {
+					Set(r, k, v)
+				}
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,99 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConn(t *testing.T) {
+	var states int32
+	h := ClearHandler(ConnHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, ok := Conn(r)
+		if !ok {
+			t.Error("Expected a connection")
+			return
+		}
+		if v := Get(r, key1); v != nil {
+			io.WriteString(w, v.(string))
+			return
+		}
+		ConnSet(c, key1, "conn value")
+		if ConnGet(c, key1) != "conn value" {
+			t.Error("Unexpected connection value")
+		}
+	})))
+	srv := httptest.NewUnstartedServer(h)
+	srv.Config.ConnState = func(c net.Conn, state http.ConnState) {
+		atomic.AddInt32(&states, 1)
+	}
+	ConfigureServer(srv.Config)
+	srv.Start()
+
+	get := func() string {
+		resp, err := srv.Client().Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return string(b)
+	}
+	// The second request reuses the connection of the first one.
+	if got := get(); got != "" {
+		t.Errorf("Unexpected response %q.", got)
+	}
+	if got := get(); got != "conn value" {
+		t.Errorf("Expected the connection value, got %q.", got)
+	}
+
+	srv.Close()
+	for i := 0; conns.Len() != 0 && i < 100; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if n := conns.Len(); n != 0 {
+		t.Errorf("Expected connection values to be cleared, got %d.", n)
+	}
+	if atomic.LoadInt32(&states) == 0 {
+		t.Error("Existing ConnState wasn't called")
+	}
+}
+
+func TestTransferToConn(t *testing.T) {
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+	c, _ := net.Pipe()
+	defer c.Close()
+	defer ConnClear(c)
+
+	Set(r, key1, "1")
+	computed := false
+	SetLazy(r, key2, func() interface{} {
+		computed = true
+		return "2"
+	})
+	TransferToConn(r, c)
+
+	if v := ConnGet(c, key1); v != "1" {
+		t.Errorf("Expected 1, got %v.", v)
+	}
+	if v := Get(r, key1); v != nil {
+		t.Errorf("Expected the value to be moved, got %v.", v)
+	}
+
+	// Lazy values are computed once read from the connection.
+	if computed {
+		t.Error("Lazy value computed by TransferToConn")
+	}
+	if v := ConnGet(c, key2); v != "2" {
+		t.Errorf("Expected 2, got %v.", v)
+	}
+}
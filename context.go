@@ -5,103 +5,615 @@
 package context
 
 import (
+	"math"
 	"net/http"
-	"sync"
+	"runtime/debug"
+	"sync/atomic"
 	"time"
 )
 
-var (
-	mutex sync.RWMutex
-	data  = make(map[*http.Request]map[interface{}]interface{})
-	datat = make(map[*http.Request]int64)
-)
-
 // Set stores a value for a given key in a given request.
-func Set(r *http.Request, key, val interface{}) {
-	mutex.Lock()
-	if data[r] == nil {
-		data[r] = make(map[interface{}]interface{})
-		datat[r] = time.Now().Unix()
+func (reg *Registry) Set(r *http.Request, key, val interface{}) {
+	atomic.AddUint64(&reg.counters.sets, 1)
+	reg.lock()
+	r = reg.resolve(r)
+	val, err := reg.checkSet(r, key, val)
+	if err != nil {
+		reg.unlock()
+		reg.refuse(r, err)
+		return
 	}
-	data[r][key] = val
-	mutex.Unlock()
+	bad := reg.usedAfterClear(r)
+	reg.set(r, key, val)
+	task := reg.tracing(r)
+	reg.unlock()
+	if bad {
+		reg.reportUseAfterClear(r, "Set", key)
+	}
+	task.log(key, val)
 }
 
-// Get returns a value stored for a given key in a given request.
-func Get(r *http.Request, key interface{}) interface{} {
-	mutex.RLock()
-	if ctx := data[r]; ctx != nil {
-		value := ctx[key]
-		mutex.RUnlock()
+// setUnchecked is Set without the checks of frozen requests, immutable
+// keys and interceptors, for the values the package records itself.
+func (reg *Registry) setUnchecked(r *http.Request, key, val interface{}) {
+	atomic.AddUint64(&reg.counters.sets, 1)
+	reg.lock()
+	reg.set(reg.resolve(r), key, val)
+	reg.unlock()
+}
+
+// set is Set without the lock, for a resolved request.
+func (reg *Registry) set(r *http.Request, key, val interface{}) {
+	reg.setUntil(r, key, val, time.Time{}, "Set")
+}
+
+// setUntil is set for a value expiring at exp, or never if exp is zero,
+// recording op in the history.
+func (reg *Registry) setUntil(r *http.Request, key, val interface{}, exp time.Time, op string) {
+	if !reg.register(r) {
+		return
+	}
+	reg.shadow(r, key)
+	reg.insert(r, key)
+	reg.data[r][key] = val
+	reg.observeSize(r, key)
+	reg.record(r, op, key, val)
+	reg.countKey(key, false)
+	reg.touch(r)
+	if exp.IsZero() {
+		delete(reg.expires[r], key)
+	} else {
+		if reg.expires[r] == nil {
+			reg.expires[r] = make(map[interface{}]time.Time)
+		}
+		reg.expires[r][key] = exp
+	}
+	reg.notify(r, key, val)
+	reg.publish(r)
+	reg.afterSet(r, key, val)
+}
+
+// del removes the value of a given key in a given resolved request,
+// recording op in the history. The caller must hold the lock and publish
+// the request afterwards.
+func (reg *Registry) del(r *http.Request, key interface{}, op string) {
+	reg.shadow(r, key)
+	delete(reg.data[r], key)
+	delete(reg.expires[r], key)
+	delete(reg.order[r], key)
+	reg.record(r, op, key, nil)
+	reg.notify(r, key, nil)
+	reg.afterDelete(r, key)
+}
+
+// register initializes the data for a given request, if not done yet.
+// It reports whether the request is registered, which is only false when
+// the registry is full. It must be called with the lock held.
+func (reg *Registry) register(r *http.Request) bool {
+	if _, ok := reg.evicting[r]; ok {
+		atomic.AddUint64(&reg.counters.rejected, 1)
+		return false
+	}
+	if reg.data[r] == nil {
+		if reg.maxEntries > 0 && len(reg.data)-len(reg.evicting) >= reg.maxEntries && !reg.makeRoom(r) {
+			return false
+		}
+		reg.add(r, reg.newValues(), reg.now(r).Unix())
+		reg.enqueue(r)
+		if reg.idle {
+			t := reg.datat[r]
+			reg.access[r] = &t
+		}
+		if reg.captureStacks {
+			reg.stacks[r] = debug.Stack()
+		}
+		if reg.leakReport != nil {
+			reg.watchLeak(r)
+		}
+	}
+	return true
+}
+
+// Get returns a value stored for a given key in a given request, or the
+// global default of the key set with SetGlobalDefault.
+func (reg *Registry) Get(r *http.Request, key interface{}) interface{} {
+	atomic.AddUint64(&reg.counters.gets, 1)
+	reg.countKey(key, true)
+	if s := reg.loadPublished(r); s != nil {
+		s.touch()
+		if value, ok := s.values[key]; ok {
+			return force(value)
+		}
+		value, _ := reg.globalDefault(key)
 		return value
 	}
-	mutex.RUnlock()
-	return nil
+	reg.rlock()
+	r = reg.resolve(r)
+	reg.touch(r)
+	if ctx := reg.data[r]; ctx != nil && !reg.expired(r, key) {
+		value, ok := ctx[key]
+		reg.mutex.RUnlock()
+		if ok {
+			return force(value)
+		}
+		value, _ = reg.globalDefault(key)
+		return value
+	}
+	bad := reg.usedAfterClear(r)
+	reg.mutex.RUnlock()
+	if bad {
+		reg.reportUseAfterClear(r, "Get", key)
+	}
+	value, _ := reg.globalDefault(key)
+	return value
 }
 
 // GetOk returns stored value and presence state like multi-value return of map access.
-func GetOk(r *http.Request, key interface{}) (interface{}, bool) {
-	mutex.RLock()
-	if _, ok := data[r]; ok {
-		value, ok := data[r][key]
-		mutex.RUnlock()
-		return value, ok
+// The global default of the key set with SetGlobalDefault counts as present.
+func (reg *Registry) GetOk(r *http.Request, key interface{}) (interface{}, bool) {
+	atomic.AddUint64(&reg.counters.gets, 1)
+	reg.countKey(key, true)
+	if s := reg.loadPublished(r); s != nil {
+		s.touch()
+		if value, ok := s.values[key]; ok {
+			return force(value), true
+		}
+		return reg.globalDefault(key)
 	}
-	mutex.RUnlock()
-	return nil, false
+	reg.rlock()
+	r = reg.resolve(r)
+	reg.touch(r)
+	if _, ok := reg.data[r]; ok && !reg.expired(r, key) {
+		value, ok := reg.data[r][key]
+		reg.mutex.RUnlock()
+		if ok {
+			return force(value), true
+		}
+		return reg.globalDefault(key)
+	}
+	bad := reg.usedAfterClear(r)
+	reg.mutex.RUnlock()
+	if bad {
+		reg.reportUseAfterClear(r, "GetOk", key)
+	}
+	return reg.globalDefault(key)
+}
+
+// GetOrCompute returns the value stored for a given key in a given request.
+// If no value is stored, fn is called and its result is stored and returned.
+//
+// The lookup and the store happen atomically, so fn is called at most once
+// per key even when several goroutines race. fn runs while the registry is
+// locked and must not use it.
+//
+// The result of fn goes through the validators and interceptors of the
+// key, like a value stored with Set. If it's refused, nothing is stored
+// and nil is returned, see HandleRefusedWrites.
+func (reg *Registry) GetOrCompute(r *http.Request, key interface{}, fn func() interface{}) interface{} {
+	atomic.AddUint64(&reg.counters.gets, 1)
+	reg.countKey(key, true)
+	value, err := reg.getOrCompute(r, key, fn)
+	if err != nil {
+		reg.refuse(r, err)
+		return nil
+	}
+	return value
+}
+
+// getOrCompute is GetOrCompute returning the error refusing the result of
+// fn instead of refusing it.
+func (reg *Registry) getOrCompute(r *http.Request, key interface{}, fn func() interface{}) (interface{}, error) {
+	reg.lock()
+	// fn may panic.
+	defer reg.unlock()
+	r = reg.resolve(r)
+	if value, ok := reg.data[r][key]; ok && !reg.expired(r, key) {
+		return force(value), nil
+	}
+	atomic.AddUint64(&reg.counters.sets, 1)
+	value, err := beforeSet(r, key, fn())
+	if err != nil {
+		return nil, err
+	}
+	reg.set(r, key, value)
+	return value, nil
 }
 
 // GetAll returns all stored values for the request as a map. Nil is returned for invalid requests.
-func GetAll(r *http.Request) map[interface{}]interface{} {
-	mutex.RLock()
-	if context, ok := data[r]; ok {
+func (reg *Registry) GetAll(r *http.Request) map[interface{}]interface{} {
+	reg.rlock()
+	r = reg.resolve(r)
+	reg.touch(r)
+	if context, ok := reg.data[r]; ok {
 		result := make(map[interface{}]interface{}, len(context))
 		for k, v := range context {
-			result[k] = v
+			if !reg.expired(r, k) {
+				result[k] = v
+			}
 		}
-		mutex.RUnlock()
+		reg.mutex.RUnlock()
+		forceAll(result)
 		return result
 	}
-	mutex.RUnlock()
+	reg.mutex.RUnlock()
 	return nil
 }
 
 // GetAllOk returns all stored values for the request as a map and a boolean value that indicates if
 // the request was registered.
-func GetAllOk(r *http.Request) (map[interface{}]interface{}, bool) {
-	mutex.RLock()
-	context, ok := data[r]
+func (reg *Registry) GetAllOk(r *http.Request) (map[interface{}]interface{}, bool) {
+	reg.rlock()
+	r = reg.resolve(r)
+	context, ok := reg.data[r]
 	result := make(map[interface{}]interface{}, len(context))
 	for k, v := range context {
-		result[k] = v
+		if !reg.expired(r, k) {
+			result[k] = v
+		}
 	}
-	mutex.RUnlock()
+	reg.mutex.RUnlock()
+	forceAll(result)
 	return result, ok
 }
 
+// rawValues is GetAll without computing the values stored with SetLazy,
+// for the functions only looking at the keys, or moving the values.
+func (reg *Registry) rawValues(r *http.Request) map[interface{}]interface{} {
+	reg.rlock()
+	defer reg.mutex.RUnlock()
+	r = reg.resolve(r)
+	context, ok := reg.data[r]
+	if !ok {
+		return nil
+	}
+	result := make(map[interface{}]interface{}, len(context))
+	for k, v := range context {
+		if !reg.expired(r, k) {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// Range calls fn for each value stored for a given request, stopping early
+// if fn returns false. Unlike GetAll, it doesn't copy the values. The
+// values are visited in insertion order if the registry was created with
+// WithInsertionOrder.
+//
+// fn runs while the registry is locked for reading and must not modify
+// values stored in it.
+func (reg *Registry) Range(r *http.Request, fn func(key, val interface{}) bool) {
+	reg.rlock()
+	defer reg.mutex.RUnlock()
+	r = reg.resolve(r)
+	if reg.order != nil {
+		for _, k := range reg.keysOf(r) {
+			if !reg.expired(r, k) && !fn(k, force(reg.data[r][k])) {
+				return
+			}
+		}
+		return
+	}
+	for k, v := range reg.data[r] {
+		if !reg.expired(r, k) && !fn(k, force(v)) {
+			return
+		}
+	}
+}
+
 // Delete removes a value stored for a given key in a given request.
-func Delete(r *http.Request, key interface{}) {
-	mutex.Lock()
-	if data[r] != nil {
-		delete(data[r], key)
+func (reg *Registry) Delete(r *http.Request, key interface{}) {
+	reg.lock()
+	r = reg.resolve(r)
+	if err := reg.checkWrite(r, key); err != nil {
+		reg.unlock()
+		reg.refuse(r, err)
+		return
+	}
+	if reg.data[r] != nil {
+		reg.del(r, key, "Delete")
+		reg.publish(r)
 	}
-	mutex.Unlock()
+	reg.unlock()
+}
+
+// OnClear registers a function to be called when the values of a given
+// request are cleared, either by Clear or by Purge.
+//
+// Functions are called in the reverse order they were registered. Clear
+// calls them before removing the values, so they can still read them.
+func (reg *Registry) OnClear(r *http.Request, fn func()) {
+	reg.lock()
+	r = reg.resolve(r)
+	// The functions registered while the request is evicted still run.
+	if _, ok := reg.evicting[r]; ok || reg.register(r) {
+		reg.hooks[r] = append(reg.hooks[r], fn)
+	}
+	reg.unlock()
+}
+
+// Clear removes all values stored for a given request.
+//
+// Clearing a request linked to another one with Link only removes the
+// link; the values are kept for the original request. Clearing a request
+// held with Retain is deferred until it's released.
+func (reg *Registry) Clear(r *http.Request) {
+	atomic.AddUint64(&reg.counters.clears, 1)
+	reg.lock()
+	if _, ok := reg.links[r]; ok {
+		reg.unlink(r)
+		reg.unlock()
+		return
+	}
+	if ret := reg.retains[r]; ret != nil {
+		ret.cleared = true
+		reg.unlock()
+		return
+	}
+	reg.clearAndUnlock(r)
+}
+
+// ClearExcept removes all values stored for a given request, except the
+// values of the given keys. Unlike Clear, the request stays registered and
+// its OnClear functions aren't called. Nothing is removed if the deletion
+// of any value is refused, see Freeze and MarkImmutable.
+func (reg *Registry) ClearExcept(r *http.Request, keys ...interface{}) {
+	reg.lock()
+	r = reg.resolve(r)
+	reg.deleteAndUnlock(r, reg.keysExcept(r, keys))
+}
+
+// tryClearExcept is ClearExcept reporting whether the values were removed
+// instead of refusing the deletion.
+func (reg *Registry) tryClearExcept(r *http.Request, keys ...interface{}) bool {
+	reg.lock()
+	defer reg.unlock()
+	r = reg.resolve(r)
+	return reg.deleteKeys(r, reg.keysExcept(r, keys)) == nil
+}
+
+// keysExcept returns the keys of the values stored for a given resolved
+// request that aren't in keys. It must be called with the lock held.
+func (reg *Registry) keysExcept(r *http.Request, keys []interface{}) []interface{} {
+	var other []interface{}
+	for k := range reg.data[r] {
+		if !containsKey(keys, k) {
+			other = append(other, k)
+		}
+	}
+	return other
+}
+
+// containsKey reports whether keys contains key.
+func containsKey(keys []interface{}, key interface{}) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// ClearAll removes the values stored for all requests, and returns the
+// amount of requests removed. Like Clear, it calls the OnClear functions
+// of each request before removing its values, including for retained
+// requests.
+func (reg *Registry) ClearAll() int {
+	reg.lock()
+	var fns []func()
+	for r := range reg.data {
+		fns = reg.takeHooks(fns, r)
+	}
+	reg.unlock()
+	runHooks(fns)
+	reg.lock()
+	count := len(reg.data)
+	for r := range reg.data {
+		reg.clear(r)
+		reg.markCleared(r)
+	}
+	reg.unlock()
+	atomic.AddUint64(&reg.counters.clears, uint64(count))
+	return count
+}
+
+// clearAndUnlock runs the OnClear functions of a given request and removes
+// its values. It must be called with the lock held, and releases it.
+func (reg *Registry) clearAndUnlock(r *http.Request) {
+	fns := reg.takeHooks(nil, r)
+	reg.unlock()
+	runHooks(fns)
+	reg.lock()
+	reg.clear(r)
+	reg.markCleared(r)
+	reg.unlock()
+}
+
+// clear is Clear without the lock.
+func (reg *Registry) clear(r *http.Request) {
+	if values := reg.data[r]; values != nil {
+		reg.putValues(values)
+	}
+	reg.unpublish(r)
+	reg.requestScope.clear(r)
+	reg.dequeue(r)
+	delete(reg.evicting, r)
+	delete(reg.access, r)
+	delete(reg.expires, r)
+	delete(reg.stacks, r)
+	delete(reg.retains, r)
+	delete(reg.history, r)
+	delete(reg.origins, r)
+	delete(reg.order, r)
+	delete(reg.clocks, r)
+	delete(reg.scopes, r)
+	delete(reg.frozen, r)
+	reg.closeWatchers(r)
+	delete(reg.flights, r)
+	delete(reg.memos, r)
+	for _, clone := range reg.clones[r] {
+		delete(reg.links, clone)
+	}
+	delete(reg.clones, r)
+}
+
+// runHooks calls the functions returned by takeHooks. It must be called
+// without the lock held.
+func runHooks(fns []func()) {
+	for _, fn := range fns {
+		fn()
+	}
+}
+
+// Purge removes request data stored for longer than maxAge, in seconds.
+// It returns the amount of requests removed.
+//
+// If maxAge <= 0, all request data is removed.
+//
+// OnClear functions of the removed requests are called after their values
+// are gone.
+func (reg *Registry) Purge(maxAge int) int {
+	reg.lock()
+	var count int
+	var fns []func()
+	if maxAge <= 0 {
+		count, fns = reg.purge(math.MaxInt64)
+	} else {
+		count, fns = reg.purge(now().Unix() - int64(maxAge))
+	}
+	reg.unlock()
+	runHooks(fns)
+	return count
+}
+
+// purge removes request data stored before min, a Unix time in seconds,
+// and values stored with SetWithTTL that expired. It must be called with
+// the lock held, and returns the amount of requests removed along with
+// their OnClear functions.
+func (reg *Registry) purge(min int64) (int, []func()) {
+	count := 0
+	var fns []func()
+	for r := range reg.data {
+		if reg.lastActive(r) < min {
+			fns = reg.purgeRequest(fns, r)
+			count++
+		}
+	}
+	reg.reapExpired()
+	reg.purgeCleared(min)
+	atomic.AddUint64(&reg.counters.purged, uint64(count))
+	atomic.AddUint64(&reg.counters.purges, 1)
+	reg.lastPurge = now().UnixNano()
+	return count, fns
+}
+
+// Set stores a value for a given key in a given request.
+func Set(r *http.Request, key, val interface{}) {
+	DefaultStore().Set(r, key, val)
+}
+
+// Get returns a value stored for a given key in a given request, or the
+// global default of the key set with SetGlobalDefault.
+func Get(r *http.Request, key interface{}) interface{} {
+	return DefaultStore().Get(r, key)
+}
+
+// GetOk returns stored value and presence state like multi-value return of map access.
+// The global default of the key set with SetGlobalDefault counts as present.
+func GetOk(r *http.Request, key interface{}) (interface{}, bool) {
+	return DefaultStore().GetOk(r, key)
+}
+
+// GetOrCompute returns the value stored for a given key in a given request.
+// If no value is stored, fn is called and its result is stored and returned.
+//
+// The lookup and the store happen atomically, so fn is called at most once
+// per key even when several goroutines race. fn runs while the context is
+// locked and must not call other functions from this package.
+//
+// The result of fn goes through the validators and interceptors of the
+// key, like a value stored with Set. If it's refused, nothing is stored
+// and nil is returned, see HandleRefusedWrites.
+func GetOrCompute(r *http.Request, key interface{}, fn func() interface{}) interface{} {
+	return defaultRegistry("GetOrCompute").GetOrCompute(r, key, fn)
+}
+
+// GetAll returns all stored values for the request as a map. Nil is returned for invalid requests.
+func GetAll(r *http.Request) map[interface{}]interface{} {
+	return DefaultStore().GetAll(r)
+}
+
+// GetAllOk returns all stored values for the request as a map and a boolean value that indicates if
+// the request was registered.
+func GetAllOk(r *http.Request) (map[interface{}]interface{}, bool) {
+	if values := DefaultStore().GetAll(r); values != nil {
+		return values, true
+	}
+	return make(map[interface{}]interface{}), false
+}
+
+// Range calls fn for each value stored for a given request, stopping early
+// if fn returns false. Unlike GetAll, it doesn't copy the values.
+//
+// fn runs while the context is locked for reading and must not call
+// functions from this package that modify values.
+func Range(r *http.Request, fn func(key, val interface{}) bool) {
+	defaultRegistry("Range").Range(r, fn)
+}
+
+// Delete removes a value stored for a given key in a given request.
+func Delete(r *http.Request, key interface{}) {
+	DefaultStore().Delete(r, key)
+}
+
+// OnClear registers a function to be called when the values of a given
+// request are cleared, either by Clear or by Purge.
+//
+// Functions are called in the reverse order they were registered. Clear
+// calls them before removing the values, so they can still read them.
+func OnClear(r *http.Request, fn func()) {
+	defaultRegistry("OnClear").OnClear(r, fn)
 }
 
 // Clear removes all values stored for a given request.
 //
 // This is usually called by a handler wrapper to clean up request
 // variables at the end of a request lifetime. See ClearHandler().
+//
+// Clearing a request linked to another one with Link only removes the
+// link; the values are kept for the original request. Clearing a request
+// held with Retain is deferred until it's released.
 func Clear(r *http.Request) {
-	mutex.Lock()
-	clear(r)
-	mutex.Unlock()
+	DefaultStore().Clear(r)
 }
 
-// clear is Clear without the lock.
-func clear(r *http.Request) {
-	delete(data, r)
-	delete(datat, r)
+// ClearExcept removes all values stored for a given request, except the
+// values of the given keys. Unlike Clear, the request stays registered and
+// its OnClear functions aren't called. Nothing is removed if the deletion
+// of any value is refused, see Freeze and MarkImmutable.
+func ClearExcept(r *http.Request, keys ...interface{}) {
+	defaultRegistry("ClearExcept").ClearExcept(r, keys...)
+}
+
+// ClearAll removes the values stored for all requests, and returns the
+// amount of requests removed. Like Clear, it calls the OnClear functions
+// of each request before removing its values, including for retained
+// requests.
+//
+// It's meant for graceful shutdowns, so that resources held in request
+// values are released. See ClearOnShutdown.
+func ClearAll() int {
+	return defaultRegistry("ClearAll").ClearAll()
+}
+
+// ClearOnShutdown registers ClearAll to be called when srv is shut down,
+// with http.Server.RegisterOnShutdown. The server calls it as soon as
+// Shutdown is called, while active requests may still be running: call
+// ClearAll after Shutdown returns instead if they must keep their values.
+func ClearOnShutdown(srv *http.Server) {
+	srv.RegisterOnShutdown(func() {
+		ClearAll()
+	})
 }
 
 // Purge removes request data stored for longer than maxAge, in seconds.
@@ -113,24 +625,11 @@ func clear(r *http.Request) {
 // properly set some request data can be kept forever, consuming an increasing
 // amount of memory. In case this is detected, Purge() must be called
 // periodically until the problem is fixed.
+//
+// OnClear functions of the removed requests are called after their values
+// are gone.
 func Purge(maxAge int) int {
-	mutex.Lock()
-	count := 0
-	if maxAge <= 0 {
-		count = len(data)
-		data = make(map[*http.Request]map[interface{}]interface{})
-		datat = make(map[*http.Request]int64)
-	} else {
-		min := time.Now().Unix() - int64(maxAge)
-		for r := range data {
-			if datat[r] < min {
-				clear(r)
-				count++
-			}
-		}
-	}
-	mutex.Unlock()
-	return count
+	return DefaultStore().Purge(maxAge)
 }
 
 // ClearHandler wraps an http.Handler and clears request values at the end
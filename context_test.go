@@ -5,8 +5,10 @@
 package context
 
 import (
+	gocontext "context"
 	"net/http"
 	"testing"
+	"time"
 )
 
 type keyType int
@@ -32,11 +34,11 @@ func TestContext(t *testing.T) {
 	// Set()
 	Set(r, key1, "1")
 	assertEqual(Get(r, key1), "1")
-	assertEqual(len(data[r]), 1)
+	assertEqual(len(builtin.data[r]), 1)
 
 	Set(r, key2, "2")
 	assertEqual(Get(r, key2), "2")
-	assertEqual(len(data[r]), 2)
+	assertEqual(len(builtin.data[r]), 2)
 
 	//GetOk
 	value, ok := GetOk(r, key1)
@@ -75,15 +77,212 @@ func TestContext(t *testing.T) {
 	// Delete()
 	Delete(r, key1)
 	assertEqual(Get(r, key1), nil)
-	assertEqual(len(data[r]), 2)
+	assertEqual(len(builtin.data[r]), 2)
 
 	Delete(r, key2)
 	assertEqual(Get(r, key2), nil)
-	assertEqual(len(data[r]), 1)
+	assertEqual(len(builtin.data[r]), 1)
 
 	// Clear()
 	Clear(r)
-	assertEqual(len(data), 0)
+	assertEqual(len(builtin.data), 0)
+}
+
+func TestGetOrCompute(t *testing.T) {
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+
+	calls := 0
+	compute := func() interface{} {
+		calls++
+		return "computed"
+	}
+
+	if v := GetOrCompute(r, key1, compute); v != "computed" {
+		t.Errorf("Expected computed, got %v.", v)
+	}
+	if v := GetOrCompute(r, key1, compute); v != "computed" {
+		t.Errorf("Expected computed, got %v.", v)
+	}
+	if calls != 1 {
+		t.Errorf("Expected fn to be called once, got %d.", calls)
+	}
+
+	// An existing value, even nil, is returned as is.
+	Set(r, key2, nil)
+	if v := GetOrCompute(r, key2, compute); v != nil {
+		t.Errorf("Expected nil, got %v.", v)
+	}
+	if calls != 1 {
+		t.Errorf("Expected fn to be called once, got %d.", calls)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	r1, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	r2, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+
+	Set(r1, key1, "1")
+	Set(r2, key1, "2")
+
+	// Nothing is old enough to be purged.
+	assertEqual(Purge(60), 0)
+	assertEqual(len(builtin.data), 2)
+
+	// Age r1 past the limit.
+	builtin.datat[r1] -= 120
+	assertEqual(Purge(60), 1)
+	assertEqual(Get(r1, key1), nil)
+	assertEqual(Get(r2, key1), "2")
+
+	// maxAge <= 0 removes everything.
+	Set(r1, key1, "1")
+	assertEqual(Purge(0), 2)
+	assertEqual(len(builtin.data), 0)
+	assertEqual(len(builtin.datat), 0)
+}
+
+func TestOnClear(t *testing.T) {
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+
+	var order []int
+	Set(r, key1, "1")
+	OnClear(r, func() {
+		// Values are still readable while hooks run.
+		if Get(r, key1) != "1" {
+			t.Error("Value cleared before OnClear hook ran")
+		}
+		order = append(order, 1)
+	})
+	OnClear(r, func() { order = append(order, 2) })
+
+	Clear(r)
+	if len(order) != 2 || order[0] != 2 || order[1] != 1 {
+		t.Errorf("Expected hooks to run in LIFO order, got %v.", order)
+	}
+	if len(builtin.hooks) != 0 {
+		t.Error("Hooks weren't removed by Clear")
+	}
+
+	// Clearing again doesn't call the hooks twice.
+	Clear(r)
+	if len(order) != 2 {
+		t.Errorf("Expected hooks to run once, got %v.", order)
+	}
+
+	// Purge calls hooks of removed requests.
+	called := false
+	OnClear(r, func() { called = true })
+	Purge(0)
+	if !called {
+		t.Error("Purge didn't call OnClear hook")
+	}
+}
+
+func TestRange(t *testing.T) {
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+
+	count := 0
+	Range(r, func(key, val interface{}) bool {
+		count++
+		return true
+	})
+	if count != 0 {
+		t.Errorf("Expected no values, got %d.", count)
+	}
+
+	Set(r, key1, "1")
+	Set(r, key2, "2")
+	seen := make(map[interface{}]interface{})
+	Range(r, func(key, val interface{}) bool {
+		seen[key] = val
+		return true
+	})
+	if len(seen) != 2 || seen[key1] != "1" || seen[key2] != "2" {
+		t.Errorf("Unexpected values %v.", seen)
+	}
+
+	// Returning false stops the iteration.
+	count = 0
+	Range(r, func(key, val interface{}) bool {
+		count++
+		return false
+	})
+	if count != 1 {
+		t.Errorf("Expected Range to stop after 1 value, got %d.", count)
+	}
+}
+
+func TestClearExcept(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+
+	cleared := false
+	Set(r, key1, "1")
+	Set(r, key2, "2")
+	OnClear(r, func() { cleared = true })
+
+	ClearExcept(r, key2)
+	assertEqual(Get(r, key1), nil)
+	assertEqual(Get(r, key2), "2")
+	assertEqual(cleared, false)
+
+	// The request stays registered when nothing is kept.
+	ClearExcept(r)
+	_, ok := GetAllOk(r)
+	assertEqual(ok, true)
+	assertEqual(len(GetAll(r)), 0)
+}
+
+func TestClearAll(t *testing.T) {
+	reg := New()
+	r1, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	r2, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+
+	var seen []interface{}
+	reg.Set(r1, key1, "1")
+	reg.OnClear(r1, func() { seen = append(seen, reg.Get(r1, key1)) })
+	reg.Set(r2, key1, "2")
+	reg.Retain(r2)
+
+	if n := reg.ClearAll(); n != 2 {
+		t.Errorf("Expected 2 requests cleared, got %d.", n)
+	}
+	// OnClear functions can still read the values.
+	if len(seen) != 1 || seen[0] != "1" {
+		t.Errorf("Unexpected values seen by OnClear: %v.", seen)
+	}
+	if s := reg.Stats(); s.Requests != 0 || s.Clears != 2 {
+		t.Errorf("Unexpected stats %+v.", s)
+	}
+}
+
+func TestClearOnShutdown(t *testing.T) {
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	Set(r, key1, "1")
+
+	srv := &http.Server{}
+	ClearOnShutdown(srv)
+	srv.Shutdown(gocontext.Background())
+	for i := 0; Get(r, key1) != nil && i < 100; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if Get(r, key1) != nil {
+		t.Error("Values weren't cleared on shutdown")
+	}
 }
 
 func parallelReader(r *http.Request, key string, iterations int, wait, done chan struct{}) {
@@ -159,3 +358,47 @@ func BenchmarkMutex5(b *testing.B) {
 func BenchmarkMutex6(b *testing.B) {
 	benchmarkMutex(b, 2048, 1024, 512)
 }
+
+func benchmarkGet(b *testing.B, keys int) {
+	reg := New()
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	for k := 0; k < keys; k++ {
+		reg.Set(r, keyType(k), k)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		reg.Get(r, keyType(i%keys))
+	}
+}
+
+func BenchmarkGet1(b *testing.B) {
+	benchmarkGet(b, 1)
+}
+func BenchmarkGet8(b *testing.B) {
+	benchmarkGet(b, 8)
+}
+func BenchmarkGet64(b *testing.B) {
+	benchmarkGet(b, 64)
+}
+
+func benchmarkRange(b *testing.B, opts ...Option) {
+	reg := New(opts...)
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	for k := 0; k < 8; k++ {
+		reg.Set(r, keyType(k), k)
+	}
+	count := func(key, val interface{}) bool { return true }
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		reg.Range(r, count)
+	}
+}
+
+func BenchmarkRange(b *testing.B) {
+	benchmarkRange(b)
+}
+func BenchmarkRangeInsertionOrder(b *testing.B) {
+	benchmarkRange(b, WithInsertionOrder())
+}
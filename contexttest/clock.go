@@ -0,0 +1,49 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package contexttest
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/context"
+)
+
+// Clock is a context.Clock that only moves forward when told to, for
+// testing expiration and purging without sleeping.
+type Clock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+// NewClock returns a clock set at t.
+func NewClock(t time.Time) *Clock {
+	return &Clock{now: t}
+}
+
+// Now returns the time the clock is set at.
+func (c *Clock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d.
+func (c *Clock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	c.now = c.now.Add(d)
+	c.mutex.Unlock()
+}
+
+// SetDefaultClock installs c as the default clock for the duration of a
+// test, restoring the system clock when it completes. Tests using it must
+// not run in parallel.
+func SetDefaultClock(t testing.TB, c context.Clock) {
+	context.SetDefaultClock(c)
+	t.Cleanup(func() {
+		context.SetDefaultClock(nil)
+	})
+}
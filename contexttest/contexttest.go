@@ -0,0 +1,204 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package contexttest provides utilities for testing handlers that use
+// request values.
+//
+// NewRequest arranges the values a handler expects, AssertCleared checks
+// that they were cleared, VerifyNoLeaks checks it for all requests, and
+// Recorder records the operations on the store:
+//
+//	func TestHandler(t *testing.T) {
+//		rec := contexttest.NewRecorder()
+//		contexttest.SetDefaultStore(t, rec)
+//
+//		r := contexttest.NewRequest(map[interface{}]interface{}{userKey: "alice"})
+//		context.ClearHandler(h).ServeHTTP(httptest.NewRecorder(), r)
+//
+//		contexttest.AssertCleared(t, r)
+//		// Inspect rec.Ops()...
+//	}
+package contexttest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/context"
+)
+
+// NewRequest returns a new GET request for "/", with the given values
+// stored in the default store.
+func NewRequest(values map[interface{}]interface{}) *http.Request {
+	r := httptest.NewRequest("GET", "/", nil)
+	for k, v := range values {
+		context.Set(r, k, v)
+	}
+	return r
+}
+
+// AssertCleared reports an error if values are stored for a given request
+// in the default store.
+func AssertCleared(t testing.TB, r *http.Request) {
+	t.Helper()
+	values, ok := context.GetAllOk(r)
+	if !ok {
+		return
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, fmt.Sprint(k))
+	}
+	t.Errorf("contexttest: request %s %s not cleared, keys %v", r.Method, r.URL, keys)
+}
+
+// SetDefaultStore installs s as the default store for the duration of a
+// test, restoring the previous one when it completes. Tests using it
+// must not run in parallel.
+func SetDefaultStore(t testing.TB, s context.Store) {
+	prev := context.DefaultStore()
+	context.SetDefaultStore(s)
+	t.Cleanup(func() {
+		context.SetDefaultStore(prev)
+	})
+}
+
+// leakChecker is implemented by the stores VerifyNoLeaks supports: the
+// *context.Registry, and the types embedding one.
+type leakChecker interface {
+	CaptureStacks(enabled bool)
+	Leaks() []context.Leak
+}
+
+// VerifyNoLeaks makes a test fail if requests it stored values for in the
+// default store are not cleared when it completes, reporting their keys
+// and the stack trace of the call that first stored a value. Requests
+// registered before the call are ignored:
+//
+//	func TestHandler(t *testing.T) {
+//		contexttest.VerifyNoLeaks(t)
+//		// ...
+//	}
+//
+// It must be called after SetDefaultStore, if both are used. Tests using
+// it must not run in parallel.
+func VerifyNoLeaks(t testing.TB) {
+	t.Helper()
+	s, ok := context.DefaultStore().(leakChecker)
+	if !ok {
+		t.Fatalf("contexttest: VerifyNoLeaks requires the default store to be a *context.Registry")
+	}
+	before := make(map[*http.Request]bool)
+	for _, l := range s.Leaks() {
+		before[l.Request] = true
+	}
+	s.CaptureStacks(true)
+	t.Cleanup(func() {
+		s.CaptureStacks(false)
+		for _, l := range s.Leaks() {
+			if before[l.Request] {
+				continue
+			}
+			keys := make([]string, 0, len(l.Keys))
+			for _, k := range l.Keys {
+				keys = append(keys, fmt.Sprint(k))
+			}
+			t.Errorf("contexttest: leaked request %s %s, keys %v, first stored at:\n%s",
+				l.Request.Method, l.Request.URL, keys, l.Stack)
+		}
+	})
+}
+
+// Op is an operation recorded by a Recorder.
+type Op struct {
+	// Name is the name of the Store method called.
+	Name    string
+	Request *http.Request
+	// Key and Value are the arguments, if any. For Purge, Value holds
+	// maxAge.
+	Key   interface{}
+	Value interface{}
+}
+
+// Recorder is a Store recording the operations it performs. It embeds a
+// *context.Registry storing the values, so that it supports all the
+// functions of the context package when installed as the default store.
+// Only the methods of the Store interface are recorded.
+type Recorder struct {
+	*context.Registry
+
+	mutex sync.Mutex
+	ops   []Op
+}
+
+// NewRecorder returns a new Recorder, backed by a new Registry.
+func NewRecorder() *Recorder {
+	return &Recorder{Registry: context.New()}
+}
+
+// record appends an operation.
+func (rec *Recorder) record(op Op) {
+	rec.mutex.Lock()
+	rec.ops = append(rec.ops, op)
+	rec.mutex.Unlock()
+}
+
+// Ops returns the operations recorded so far, in order.
+func (rec *Recorder) Ops() []Op {
+	rec.mutex.Lock()
+	defer rec.mutex.Unlock()
+	return append([]Op(nil), rec.ops...)
+}
+
+// Reset forgets the operations recorded so far.
+func (rec *Recorder) Reset() {
+	rec.mutex.Lock()
+	rec.ops = nil
+	rec.mutex.Unlock()
+}
+
+// Set records the call and stores a value.
+func (rec *Recorder) Set(r *http.Request, key, val interface{}) {
+	rec.record(Op{Name: "Set", Request: r, Key: key, Value: val})
+	rec.Registry.Set(r, key, val)
+}
+
+// Get records the call and returns a stored value.
+func (rec *Recorder) Get(r *http.Request, key interface{}) interface{} {
+	rec.record(Op{Name: "Get", Request: r, Key: key})
+	return rec.Registry.Get(r, key)
+}
+
+// GetOk records the call and returns a stored value and its presence.
+func (rec *Recorder) GetOk(r *http.Request, key interface{}) (interface{}, bool) {
+	rec.record(Op{Name: "GetOk", Request: r, Key: key})
+	return rec.Registry.GetOk(r, key)
+}
+
+// GetAll records the call and returns all the values of a request.
+func (rec *Recorder) GetAll(r *http.Request) map[interface{}]interface{} {
+	rec.record(Op{Name: "GetAll", Request: r})
+	return rec.Registry.GetAll(r)
+}
+
+// Delete records the call and removes a stored value.
+func (rec *Recorder) Delete(r *http.Request, key interface{}) {
+	rec.record(Op{Name: "Delete", Request: r, Key: key})
+	rec.Registry.Delete(r, key)
+}
+
+// Clear records the call and removes the values of a request.
+func (rec *Recorder) Clear(r *http.Request) {
+	rec.record(Op{Name: "Clear", Request: r})
+	rec.Registry.Clear(r)
+}
+
+// Purge records the call and removes old request data.
+func (rec *Recorder) Purge(maxAge int) int {
+	rec.record(Op{Name: "Purge", Value: maxAge})
+	return rec.Registry.Purge(maxAge)
+}
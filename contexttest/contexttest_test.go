@@ -0,0 +1,139 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package contexttest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/context"
+)
+
+type keyType int
+
+const key keyType = 0
+
+// fakeT records the errors reported by AssertCleared.
+type fakeT struct {
+	testing.TB
+	failed   bool
+	msg      string
+	cleanups []func()
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.failed = true
+	t.msg = fmt.Sprintf(format, args...)
+}
+
+func (t *fakeT) Cleanup(fn func()) {
+	t.cleanups = append(t.cleanups, fn)
+}
+
+// done runs the cleanup functions, like the end of a test.
+func (t *fakeT) done() {
+	for i := len(t.cleanups) - 1; i >= 0; i-- {
+		t.cleanups[i]()
+	}
+}
+
+func TestNewRequestAndAssertCleared(t *testing.T) {
+	r := NewRequest(map[interface{}]interface{}{key: "value"})
+	if v := context.Get(r, key); v != "value" {
+		t.Errorf("Expected value, got %v.", v)
+	}
+
+	ft := &fakeT{TB: t}
+	AssertCleared(ft, r)
+	if !ft.failed {
+		t.Error("AssertCleared didn't fail for a stored request")
+	}
+
+	context.Clear(r)
+	ft = &fakeT{TB: t}
+	AssertCleared(ft, r)
+	if ft.failed {
+		t.Error("AssertCleared failed for a cleared request")
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	rec := NewRecorder()
+	SetDefaultStore(t, rec)
+
+	h := context.ClearHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		context.Set(r, key, "value")
+		context.OnClear(r, func() {})
+		context.Get(r, key)
+	}))
+	r := httptest.NewRequest("GET", "/", nil)
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	AssertCleared(t, r)
+
+	ops := rec.Ops()
+	want := []string{"Set", "Get", "Clear"}
+	if len(ops) < len(want) {
+		t.Fatalf("Unexpected ops %+v.", ops)
+	}
+	for i, name := range want {
+		if ops[i].Name != name || ops[i].Request != r {
+			t.Errorf("Op %d: expected %s, got %+v.", i, name, ops[i])
+		}
+	}
+	if ops[0].Key != key || ops[0].Value != "value" {
+		t.Errorf("Unexpected Set op %+v.", ops[0])
+	}
+
+	rec.Reset()
+	if len(rec.Ops()) != 0 {
+		t.Error("Reset didn't forget the ops")
+	}
+}
+
+func TestVerifyNoLeaks(t *testing.T) {
+	old := NewRequest(map[interface{}]interface{}{key: "old"})
+	defer context.Clear(old)
+
+	ft := &fakeT{TB: t}
+	VerifyNoLeaks(ft)
+	cleared := NewRequest(map[interface{}]interface{}{key: "value"})
+	context.Clear(cleared)
+	ft.done()
+	if ft.failed {
+		t.Errorf("Unexpected failure: %s", ft.msg)
+	}
+
+	ft = &fakeT{TB: t}
+	VerifyNoLeaks(ft)
+	leaked := NewRequest(map[interface{}]interface{}{key: "value"})
+	defer context.Clear(leaked)
+	ft.done()
+	if !ft.failed || !strings.Contains(ft.msg, "TestVerifyNoLeaks") {
+		t.Errorf("Leak not reported with its stack: %s", ft.msg)
+	}
+}
+
+func TestClock(t *testing.T) {
+	c := NewClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	SetDefaultClock(t, c)
+
+	r := httptest.NewRequest("GET", "/", nil)
+	defer context.Clear(r)
+	context.SetWithTTL(r, key, "value", time.Minute)
+	c.Advance(59 * time.Second)
+	if _, ok := context.GetOk(r, key); !ok {
+		t.Error("Value expired early")
+	}
+	c.Advance(time.Second)
+	if _, ok := context.GetOk(r, key); ok {
+		t.Error("Value didn't expire")
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"reflect"
+)
+
+// Local is a KeyOption marking a key whose values belong to the request
+// they're stored for, such as the state of a transaction, so that Copy
+// doesn't copy them unless the key is listed.
+func Local() KeyOption {
+	return func(info *keyInfo) {
+		info.local = true
+	}
+}
+
+// localKey reports whether the values of a given key are only copied by
+// Copy when it's listed: the bookkeeping of the package, and the keys
+// registered with Local.
+func localKey(key interface{}) bool {
+	switch key.(type) {
+	case errorsKey, flashesKey, responseHeaderKey:
+		return true
+	}
+	info := lookupKey(key)
+	return info != nil && info.local
+}
+
+// Copy stores in dst the values stored for the given keys in src, or all
+// the values of src when no keys are given, except those of the keys
+// registered with Local. Keys with no value in src are skipped. Slices are
+// copied, so that appending to them in one request doesn't change the
+// other.
+func (reg *Registry) Copy(dst, src *http.Request, keys ...interface{}) {
+	copyValues(reg, dst, src, keys)
+}
+
+// Copy stores in dst the values stored for the given keys in src, or all
+// the values of src when no keys are given, except those of the keys
+// registered with Local. Keys with no value in src are skipped. Slices are
+// copied, so that appending to them in one request doesn't change the
+// other.
+//
+// It's meant for requests built from an inbound request, such as proxied
+// requests or sub-requests, so that they carry the same authentication or
+// tracing values.
+func Copy(dst, src *http.Request, keys ...interface{}) {
+	copyValues(DefaultStore(), dst, src, keys)
+}
+
+func copyValues(s Store, dst, src *http.Request, keys []interface{}) {
+	values := s.GetAll(src)
+	if len(keys) == 0 {
+		for k, v := range values {
+			if !localKey(k) {
+				s.Set(dst, k, copySlice(v))
+			}
+		}
+		return
+	}
+	for _, k := range keys {
+		if v, ok := values[k]; ok {
+			s.Set(dst, k, copySlice(v))
+		}
+	}
+}
+
+// copySlice returns a copy of v if it's a slice, or v.
+func copySlice(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice || rv.IsNil() {
+		return v
+	}
+	c := reflect.MakeSlice(rv.Type(), rv.Len(), rv.Len())
+	reflect.Copy(c, rv)
+	return c.Interface()
+}
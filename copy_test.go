@@ -0,0 +1,74 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestCopy(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	src, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	dst1, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	dst2, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(src)
+	defer Clear(dst1)
+	defer Clear(dst2)
+
+	Set(src, key1, "1")
+	Set(src, key2, "2")
+
+	// All keys.
+	Copy(dst1, src)
+	assertEqual(len(GetAll(dst1)), 2)
+	assertEqual(Get(dst1, key2), "2")
+
+	// Selected keys; missing ones are skipped.
+	Copy(dst2, src, key1, "missing")
+	values := GetAll(dst2)
+	assertEqual(len(values), 1)
+	assertEqual(values[key1], "1")
+}
+
+func TestCopyLocal(t *testing.T) {
+	src, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	dst, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(src)
+	defer Clear(dst)
+
+	RegisterKey(localKeyType(0), Local())
+	Set(src, localKeyType(0), "local")
+	Set(src, key1, []string{"a"})
+	AddError(src, errors.New("failed"))
+	AddFlash(src, "hello")
+	Memoize(src, key2, func() (interface{}, error) { return "memo", nil })
+
+	// Only the values of user keys are copied, slices included.
+	Copy(dst, src)
+	values := GetAll(dst)
+	if len(values) != 1 {
+		t.Fatalf("Unexpected values %v.", values)
+	}
+	ids := values[key1].([]string)
+	ids[0] = "b"
+	if v := Get(src, key1).([]string); v[0] != "a" {
+		t.Errorf("Slice shared with the source: %v.", v)
+	}
+
+	// Local keys are copied when listed.
+	Copy(dst, src, localKeyType(0))
+	if v := Get(dst, localKeyType(0)); v != "local" {
+		t.Errorf("Expected local, got %v.", v)
+	}
+}
+
+type localKeyType int
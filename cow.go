@@ -0,0 +1,76 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// published is an immutable copy of the values of a request, published when
+// copy-on-write is enabled.
+type published struct {
+	values map[interface{}]interface{}
+	access *int64
+	clock  Clock
+}
+
+// touch records an access to the request of the snapshot.
+func (s *published) touch() {
+	if s.access != nil {
+		t := now()
+		if s.clock != nil {
+			t = s.clock.Now()
+		}
+		atomic.StoreInt64(s.access, t.Unix())
+	}
+}
+
+// loadPublished returns the copy of a given request, or nil if the values
+// must be read with the lock held.
+func (reg *Registry) loadPublished(r *http.Request) *published {
+	if !reg.cow {
+		return nil
+	}
+	if s, ok := reg.snapshots.Load(r); ok {
+		return s.(*published)
+	}
+	return nil
+}
+
+// publish replaces the snapshot of a given resolved request and its clones
+// after a change. Requests with values stored with SetWithTTL get no
+// snapshot, as expiration depends on the time of the read. It must be
+// called with the lock held.
+func (reg *Registry) publish(r *http.Request) {
+	if !reg.cow {
+		return
+	}
+	values, ok := reg.data[r]
+	if !ok || len(reg.expires[r]) > 0 {
+		reg.unpublish(r)
+		return
+	}
+	s := &published{values: make(map[interface{}]interface{}, len(values)), access: reg.access[r], clock: reg.clocks[r]}
+	for k, v := range values {
+		s.values[k] = v
+	}
+	reg.snapshots.Store(r, s)
+	for _, clone := range reg.clones[r] {
+		reg.snapshots.Store(clone, s)
+	}
+}
+
+// unpublish removes the snapshot of a given request and its clones. It must
+// be called with the lock held.
+func (reg *Registry) unpublish(r *http.Request) {
+	if !reg.cow {
+		return
+	}
+	reg.snapshots.Delete(r)
+	for _, clone := range reg.clones[r] {
+		reg.snapshots.Delete(clone)
+	}
+}
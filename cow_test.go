@@ -0,0 +1,80 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCopyOnWrite(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	reg := New(WithCopyOnWrite())
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer reg.Clear(r)
+
+	assertEqual(reg.Get(r, key1), nil)
+	reg.Set(r, key1, "1")
+	assertEqual(reg.loadPublished(r) != nil, true)
+	assertEqual(reg.Get(r, key1), "1")
+	val, ok := reg.GetOk(r, key1)
+	assertEqual(val, "1")
+	assertEqual(ok, true)
+
+	reg.Handle(r).Set(key2, "2")
+	assertEqual(reg.Get(r, key2), "2")
+	reg.Delete(r, key2)
+	_, ok = reg.GetOk(r, key2)
+	assertEqual(ok, false)
+
+	// Clones share the snapshot.
+	clone := r.Clone(r.Context())
+	reg.Link(r, clone)
+	reg.Set(clone, key2, "clone")
+	assertEqual(reg.Get(r, key2), "clone")
+	assertEqual(reg.Get(clone, key1), "1")
+	reg.Clear(clone)
+	assertEqual(reg.loadPublished(clone) == nil, true)
+	assertEqual(reg.Get(clone, key1), nil)
+
+	// Expiring values take the locked path.
+	reg.SetWithTTL(r, key2, "ttl", time.Millisecond)
+	assertEqual(reg.loadPublished(r) == nil, true)
+	time.Sleep(2 * time.Millisecond)
+	assertEqual(reg.Get(r, key2), nil)
+	reg.Purge(10)
+	assertEqual(reg.loadPublished(r) != nil, true)
+	assertEqual(reg.Get(r, key1), "1")
+
+	reg.Clear(r)
+	assertEqual(reg.loadPublished(r) == nil, true)
+	assertEqual(reg.Get(r, key1), nil)
+}
+
+func benchmarkGetParallel(b *testing.B, opts ...Option) {
+	reg := New(opts...)
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	reg.Set(r, key1, "1")
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			reg.Get(r, key1)
+		}
+	})
+}
+
+func BenchmarkGetParallel(b *testing.B) {
+	benchmarkGetParallel(b)
+}
+func BenchmarkGetParallelCopyOnWrite(b *testing.B) {
+	benchmarkGetParallel(b, WithCopyOnWrite())
+}
@@ -0,0 +1,126 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// debugEntry describes a registered request in DebugHandler output.
+type debugEntry struct {
+	Method string            `json:"method"`
+	URL    string            `json:"url"`
+	Age    string            `json:"age"`
+	Keys   int               `json:"keys"`
+	Values map[string]string `json:"values"`
+
+	created int64
+}
+
+var debugTemplate = template.Must(template.New("debug").Parse(`<!DOCTYPE html>
+<html>
+<head><title>gorilla/context</title></head>
+<body>
+<h1>{{len .}} registered requests</h1>
+<table>
+<tr><th>Request</th><th>Age</th><th>Keys</th><th>Values</th></tr>
+{{range .}}<tr>
+<td>{{.Method}} {{.URL}}</td>
+<td>{{.Age}}</td>
+<td>{{.Keys}}</td>
+<td>{{range $k, $v := .Values}}{{$k}}: {{$v}}<br>{{end}}</td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// DebugHandler returns an http.Handler that lists all the requests with
+// stored values, oldest first, along with their age and keys. Values are
+// redacted: only their type is shown, and not even that for sensitive
+// keys, see MarkSensitive. Keys are shown with their type, and request
+// URLs without their query string and password.
+//
+// The list is rendered as HTML, or as JSON when the "format" query
+// parameter is "json" or the request accepts application/json.
+func (reg *Registry) DebugHandler() http.Handler {
+	return debugHandler(func() *Registry { return reg })
+}
+
+// DebugHandler returns an http.Handler that lists all the requests with
+// values in the default store. See (*Registry).DebugHandler for details.
+func DebugHandler() http.Handler {
+	return debugHandler(func() *Registry {
+		return defaultRegistry("DebugHandler")
+	})
+}
+
+// debugHandler returns a handler listing the requests of the registry
+// returned by reg, which is called for every request served.
+func debugHandler(reg func() *Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entries := reg().debugEntries()
+		var buf bytes.Buffer
+		var err error
+		contentType := "text/html; charset=utf-8"
+		if r.FormValue("format") == "json" ||
+			strings.Contains(r.Header.Get("Accept"), "application/json") {
+			contentType = "application/json"
+			err = json.NewEncoder(&buf).Encode(entries)
+		} else {
+			err = debugTemplate.Execute(&buf, entries)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		buf.WriteTo(w)
+	})
+}
+
+// debugEntries returns the registered requests, oldest first.
+func (reg *Registry) debugEntries() []debugEntry {
+	reg.rlock()
+	entries := make([]debugEntry, 0, len(reg.data))
+	for r, values := range reg.data {
+		e := debugEntry{
+			Method:  r.Method,
+			Age:     (time.Duration(reg.now(r).Unix()-reg.datat[r]) * time.Second).String(),
+			Keys:    len(values),
+			Values:  make(map[string]string, len(values)),
+			created: reg.datat[r],
+		}
+		if r.URL != nil {
+			// The query string may hold secrets, such as tokens.
+			u := *r.URL
+			u.RawQuery = ""
+			u.ForceQuery = false
+			e.URL = u.Redacted()
+		}
+		for k, v := range values {
+			// The type tells apart keys printed the same.
+			name := fmt.Sprintf("%v (%T)", k, k)
+			if IsSensitive(k) {
+				e.Values[name] = Redacted
+			} else {
+				e.Values[name] = fmt.Sprintf("%T", v)
+			}
+		}
+		entries = append(entries, e)
+	}
+	reg.mutex.RUnlock()
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].created < entries[j].created
+	})
+	return entries
+}
@@ -0,0 +1,52 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDebugHandler(t *testing.T) {
+	r, _ := http.NewRequest("GET", "http://localhost:8080/leaky?token=secret", nil)
+	defer Clear(r)
+	Set(r, "user", "secret")
+	// Keys printed the same are told apart.
+	Set(r, key1, 1)
+	Set(r, "0", 2)
+
+	// JSON
+	req, _ := http.NewRequest("GET", "/debug/context?format=json", nil)
+	rec := httptest.NewRecorder()
+	DebugHandler().ServeHTTP(rec, req)
+
+	var entries []debugEntry
+	if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d.", len(entries))
+	}
+	e := entries[0]
+	if e.URL != "http://localhost:8080/leaky" || e.Keys != 3 || e.Values["user (string)"] != "string" ||
+		e.Values["0 (context.keyType)"] != "int" || e.Values["0 (string)"] != "int" {
+		t.Errorf("Unexpected entry %+v.", e)
+	}
+
+	// HTML
+	req, _ = http.NewRequest("GET", "/debug/context", nil)
+	rec = httptest.NewRecorder()
+	DebugHandler().ServeHTTP(rec, req)
+	body := rec.Body.String()
+	if !strings.Contains(body, "/leaky") {
+		t.Errorf("Request missing from HTML output:\n%s", body)
+	}
+	if strings.Contains(body, "secret") {
+		t.Errorf("Value not redacted in HTML output:\n%s", body)
+	}
+}
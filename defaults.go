@@ -0,0 +1,38 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+// SetGlobalDefault sets the value Get and GetOk return for a given key in
+// the requests with no value stored for it, such as a default locale or
+// feature flag. Requests override the default by storing a value.
+//
+// Defaults aren't request values: GetAll and the like don't return them,
+// and clearing a request doesn't remove them.
+func (reg *Registry) SetGlobalDefault(key, val interface{}) {
+	reg.defaults.Store(key, val)
+}
+
+// SetGlobalDefault sets the value Get and GetOk return for a given key in
+// the requests with no value stored for it. See
+// (*Registry).SetGlobalDefault for details.
+func SetGlobalDefault(key, val interface{}) {
+	defaultRegistry("SetGlobalDefault").SetGlobalDefault(key, val)
+}
+
+// DeleteGlobalDefault removes the global default of a given key.
+func (reg *Registry) DeleteGlobalDefault(key interface{}) {
+	reg.defaults.Delete(key)
+}
+
+// DeleteGlobalDefault removes the global default of a given key.
+func DeleteGlobalDefault(key interface{}) {
+	defaultRegistry("DeleteGlobalDefault").DeleteGlobalDefault(key)
+}
+
+// globalDefault returns the global default of a given key, and whether
+// one is set.
+func (reg *Registry) globalDefault(key interface{}) (interface{}, bool) {
+	return reg.defaults.Load(key)
+}
@@ -0,0 +1,45 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestSetGlobalDefault(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		t.Helper()
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	for _, reg := range []*Registry{New(), New(WithCopyOnWrite())} {
+		r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+		reg.SetGlobalDefault("locale", "en")
+
+		// Unregistered request
+		assertEqual(reg.Get(r, "locale"), "en")
+		v, ok := reg.GetOk(r, "locale")
+		assertEqual(v, "en")
+		assertEqual(ok, true)
+
+		// Registered request without an override
+		reg.Set(r, key1, "1")
+		assertEqual(reg.Get(r, "locale"), "en")
+		assertEqual(len(reg.GetAll(r)), 1)
+
+		reg.Set(r, "locale", "fr")
+		assertEqual(reg.Get(r, "locale"), "fr")
+		reg.Delete(r, "locale")
+		assertEqual(reg.Get(r, "locale"), "en")
+
+		reg.DeleteGlobalDefault("locale")
+		_, ok = reg.GetOk(r, "locale")
+		assertEqual(ok, false)
+		reg.Clear(r)
+	}
+}
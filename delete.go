@@ -0,0 +1,70 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+)
+
+// DeleteMatching removes the values stored for a given request for which
+// fn returns true, and returns the amount of values removed. Nothing is
+// removed if the deletion of any of them is refused, see Freeze and
+// MarkImmutable.
+//
+// fn runs while the registry is locked and must not use it.
+func (reg *Registry) DeleteMatching(r *http.Request, fn func(key, val interface{}) bool) int {
+	reg.lock()
+	r = reg.resolve(r)
+	var keys []interface{}
+	for k, v := range reg.data[r] {
+		if !reg.expired(r, k) && fn(k, force(v)) {
+			keys = append(keys, k)
+		}
+	}
+	return reg.deleteAndUnlock(r, keys)
+}
+
+// deleteAndUnlock removes the values of the given keys from a given
+// resolved request, unless the deletion of any of them is refused, and
+// returns the amount of values removed. It must be called with the lock
+// held, and releases it.
+func (reg *Registry) deleteAndUnlock(r *http.Request, keys []interface{}) int {
+	if err := reg.deleteKeys(r, keys); err != nil {
+		reg.unlock()
+		reg.refuse(r, err)
+		return 0
+	}
+	reg.unlock()
+	return len(keys)
+}
+
+// deleteKeys removes the values of the given keys from a given resolved
+// request, or returns the error refusing the deletion of any of them. It
+// must be called with the lock held.
+func (reg *Registry) deleteKeys(r *http.Request, keys []interface{}) error {
+	for _, k := range keys {
+		if err := reg.checkWrite(r, k); err != nil {
+			return err
+		}
+	}
+	for _, k := range keys {
+		reg.del(r, k, "Delete")
+	}
+	if len(keys) > 0 {
+		reg.publish(r)
+	}
+	return nil
+}
+
+// DeleteMatching removes the values stored for a given request for which
+// fn returns true, and returns the amount of values removed. It's meant
+// for dropping the values of a namespace, or large values, before handing
+// the request off to a long-lived goroutine.
+//
+// fn runs while the context is locked and must not call other functions
+// from this package.
+func DeleteMatching(r *http.Request, fn func(key, val interface{}) bool) int {
+	return defaultRegistry("DeleteMatching").DeleteMatching(r, fn)
+}
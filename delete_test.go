@@ -0,0 +1,47 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestDeleteMatching(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		t.Helper()
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+
+	Set(r, "auth.user", "alice")
+	Set(r, "auth.token", "secret")
+	Set(r, key1, "1")
+	SetLazy(r, key2, func() interface{} { return make([]byte, 1<<20) })
+
+	n := DeleteMatching(r, func(key, val interface{}) bool {
+		s, ok := key.(string)
+		return ok && len(s) > 5 && s[:5] == "auth."
+	})
+	assertEqual(n, 2)
+	assertEqual(Get(r, "auth.user"), nil)
+	assertEqual(Get(r, "auth.token"), nil)
+	assertEqual(Get(r, key1), "1")
+
+	n = DeleteMatching(r, func(key, val interface{}) bool {
+		b, ok := val.([]byte)
+		return ok && len(b) > 1<<10
+	})
+	assertEqual(n, 1)
+	_, ok := GetOk(r, key2)
+	assertEqual(ok, false)
+
+	assertEqual(DeleteMatching(r, func(key, val interface{}) bool { return false }), 0)
+	assertEqual(Get(r, key1), "1")
+}
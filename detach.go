@@ -0,0 +1,111 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+)
+
+// ReadOnlyContext gives read access to the values of a request, with no
+// means to change them. It's either a copy of the values returned by
+// Detach, which is safe for concurrent use and stays valid after the
+// request is cleared, or a view of the current values returned by
+// ReadOnly.
+type ReadOnlyContext struct {
+	values map[interface{}]interface{}
+	// s and r are set for views.
+	s Store
+	r *http.Request
+}
+
+// Detach returns a copy of the values currently stored for a given
+// request, for use by background work that outlives the request.
+func (reg *Registry) Detach(r *http.Request) ReadOnlyContext {
+	return ReadOnlyContext{values: reg.GetAll(r)}
+}
+
+// Detach returns a copy of the values currently stored for a given
+// request, for use by background work that outlives the request.
+//
+// Values stored later are not visible in the copy, and clearing the
+// request doesn't affect it:
+//
+//	ro := context.Detach(r)
+//	go func() {
+//		user := ro.Get(userKey)
+//		// ...
+//	}()
+func Detach(r *http.Request) ReadOnlyContext {
+	return ReadOnlyContext{values: DefaultStore().GetAll(r)}
+}
+
+// ReadOnly returns a view of the values of a given request that can only
+// read them, for code that must not change them, such as plugins or
+// templates. Unlike Detach, values stored later are visible.
+func (reg *Registry) ReadOnly(r *http.Request) ReadOnlyContext {
+	return ReadOnlyContext{s: reg, r: r}
+}
+
+// ReadOnly returns a view of the values of a given request that can only
+// read them, for code that must not change them, such as plugins or
+// templates. Unlike Detach, values stored later are visible.
+func ReadOnly(r *http.Request) ReadOnlyContext {
+	return ReadOnlyContext{s: DefaultStore(), r: r}
+}
+
+// Get returns the value stored for a given key.
+func (c ReadOnlyContext) Get(key interface{}) interface{} {
+	if c.s != nil {
+		return c.s.Get(c.r, key)
+	}
+	return c.values[key]
+}
+
+// GetOk returns stored value and presence state like multi-value return of map access.
+func (c ReadOnlyContext) GetOk(key interface{}) (interface{}, bool) {
+	if c.s != nil {
+		return c.s.GetOk(c.r, key)
+	}
+	value, ok := c.values[key]
+	return value, ok
+}
+
+// GetAll returns a copy of all the values.
+func (c ReadOnlyContext) GetAll() map[interface{}]interface{} {
+	if c.s != nil {
+		result := c.s.GetAll(c.r)
+		if result == nil {
+			result = make(map[interface{}]interface{})
+		}
+		return result
+	}
+	result := make(map[interface{}]interface{}, len(c.values))
+	for k, v := range c.values {
+		result[k] = v
+	}
+	return result
+}
+
+// Range calls fn for each value, stopping early if fn returns false. Views
+// returned by ReadOnly range over a copy of the values.
+func (c ReadOnlyContext) Range(fn func(key, val interface{}) bool) {
+	values := c.values
+	if c.s != nil {
+		values = c.s.GetAll(c.r)
+	}
+	for k, v := range values {
+		if !fn(k, v) {
+			return
+		}
+	}
+}
+
+// Len returns the number of values.
+func (c ReadOnlyContext) Len() int {
+	if c.s != nil {
+		return len(rawValues(c.s, c.r))
+	}
+	return len(c.values)
+}
@@ -0,0 +1,86 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"testing"
+)
+
+func TestDetach(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	Set(r, key1, "1")
+	ro := Detach(r)
+
+	Set(r, key2, "2")
+	Clear(r)
+
+	assertEqual(ro.Get(key1), "1")
+	assertEqual(ro.Get(key2), nil)
+	val, ok := ro.GetOk(key1)
+	assertEqual(val, "1")
+	assertEqual(ok, true)
+	assertEqual(ro.Len(), 1)
+
+	// GetAll returns a copy.
+	ro.GetAll()[key2] = "2"
+	assertEqual(ro.Len(), 1)
+
+	// Detaching an unregistered request returns an empty context.
+	ro = Detach(r)
+	assertEqual(ro.Len(), 0)
+	assertEqual(ro.Get(key1), nil)
+}
+
+func TestReadOnly(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		t.Helper()
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+	Set(r, key1, "1")
+
+	ro := ReadOnly(r)
+	assertEqual(ro.Get(key1), "1")
+	assertEqual(ro.Len(), 1)
+
+	// Values stored later are visible.
+	Set(r, key2, "2")
+	v, ok := ro.GetOk(key2)
+	assertEqual(v, "2")
+	assertEqual(ok, true)
+
+	seen := 0
+	ro.Range(func(key, val interface{}) bool {
+		seen++
+		return true
+	})
+	assertEqual(seen, 2)
+
+	// Templates can read values.
+	var buf bytes.Buffer
+	tmpl := template.Must(template.New("").Parse(`{{.Get "user"}}`))
+	Set(r, "user", "alice")
+	if err := tmpl.Execute(&buf, ro); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(buf.String(), "alice")
+
+	Clear(r)
+	assertEqual(ro.Len(), 0)
+	assertEqual(len(ro.GetAll()), 0)
+}
@@ -67,6 +67,13 @@ type:
 		context.Set(r, mykey, val)
 	}
 
+Alternatively, use a typed Key, which does the type assertion for you:
+
+	var MyKey = context.NewKey[SomeType]("mykey")
+
+	MyKey.Set(r, val)
+	val, ok := MyKey.Get(r)
+
 Variables must be cleared at the end of a request, to remove all values
 that were stored. This can be done in an http.Handler, after a request was
 served. Just call Clear() passing the request:
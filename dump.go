@@ -0,0 +1,123 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// DumpOption configures DumpJSON.
+type DumpOption func(*dumpConfig)
+
+type dumpConfig struct {
+	keyFunc  func(key interface{}) string
+	maxDepth int
+}
+
+// DumpKeyFunc sets the function turning keys into JSON object names. The
+// default is fmt.Sprint, falling back to the type and string form, as in
+// "context.userKey:0", for keys with the same string form.
+func DumpKeyFunc(fn func(key interface{}) string) DumpOption {
+	return func(c *dumpConfig) {
+		c.keyFunc = fn
+	}
+}
+
+// DumpMaxDepth limits the nesting of the dumped values: objects and arrays
+// nested deeper than n levels in a value are replaced with "...". The
+// default, 0, doesn't limit the nesting.
+func DumpMaxDepth(n int) DumpOption {
+	return func(c *dumpConfig) {
+		c.maxDepth = n
+	}
+}
+
+// truncated replaces the values nested deeper than DumpMaxDepth.
+const truncated = "..."
+
+// DumpJSON writes the values stored for a given request to w as a JSON
+// object, for error reports and structured logs. Values are encoded with
+// encoding/json; values that can't be encoded are replaced with their
+// type, and sensitive ones with Redacted, see MarkSensitive.
+func (reg *Registry) DumpJSON(r *http.Request, w io.Writer, opts ...DumpOption) error {
+	return dumpJSON(reg.GetAll(r), w, opts)
+}
+
+// DumpJSON writes the values stored for a given request to w as a JSON
+// object. See (*Registry).DumpJSON for details.
+func DumpJSON(r *http.Request, w io.Writer, opts ...DumpOption) error {
+	return dumpJSON(DefaultStore().GetAll(r), w, opts)
+}
+
+// dumpJSON writes values to w as configured by opts.
+func dumpJSON(values map[interface{}]interface{}, w io.Writer, opts []DumpOption) error {
+	var c dumpConfig
+	for _, opt := range opts {
+		opt(&c)
+	}
+	names := make(map[interface{}]string, len(values))
+	count := make(map[string]int, len(values))
+	for k := range values {
+		var name string
+		if c.keyFunc != nil {
+			name = c.keyFunc(k)
+		} else {
+			name = fmt.Sprint(k)
+		}
+		names[k] = name
+		count[name]++
+	}
+	out := make(map[string]interface{}, len(values))
+	for k, v := range Redact(values) {
+		name := names[k]
+		if count[name] > 1 && c.keyFunc == nil {
+			name = fmt.Sprintf("%T:%v", k, k)
+		}
+		out[name] = dumpValue(v, c.maxDepth)
+	}
+	return json.NewEncoder(w).Encode(out)
+}
+
+// dumpValue returns v as decoded from its JSON encoding, truncated to
+// maxDepth levels, or its type if it can't be encoded.
+func dumpValue(v interface{}, maxDepth int) interface{} {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%T", v)
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return fmt.Sprintf("%T", v)
+	}
+	if maxDepth > 0 {
+		decoded = truncate(decoded, maxDepth)
+	}
+	return decoded
+}
+
+// truncate replaces the objects and arrays of a decoded JSON value nested
+// deeper than depth levels.
+func truncate(v interface{}, depth int) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if depth == 0 {
+			return truncated
+		}
+		for k, e := range v {
+			v[k] = truncate(e, depth-1)
+		}
+	case []interface{}:
+		if depth == 0 {
+			return truncated
+		}
+		for i, e := range v {
+			v[i] = truncate(e, depth-1)
+		}
+	}
+	return v
+}
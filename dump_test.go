@@ -0,0 +1,54 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDumpJSON(t *testing.T) {
+	assertEqual := func(got, want string) {
+		t.Helper()
+		if strings.TrimSpace(got) != want {
+			t.Errorf("Expected %s, got %s", want, got)
+		}
+	}
+
+	MarkSensitive(passwordKey)
+	reg := New()
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer reg.Clear(r)
+
+	reg.Set(r, "user", map[string]interface{}{"name": "alice", "roles": []string{"admin"}})
+	reg.Set(r, "fn", func() {})
+	reg.Set(r, passwordKey, "secret")
+
+	var buf bytes.Buffer
+	if err := reg.DumpJSON(r, &buf); err != nil {
+		t.Fatal(err)
+	}
+	// Sensitive values are redacted, and functions replaced with their type.
+	assertEqual(buf.String(), `{"0":"[REDACTED]","fn":"func()","user":{"name":"alice","roles":["admin"]}}`)
+
+	buf.Reset()
+	reg.DumpJSON(r, &buf, DumpMaxDepth(1))
+	assertEqual(buf.String(), `{"0":"[REDACTED]","fn":"func()","user":{"name":"alice","roles":"..."}}`)
+
+	// Keys with the same string form are told apart by their type.
+	reg.Set(r, key1, 1)
+	buf.Reset()
+	reg.DumpJSON(r, &buf, DumpMaxDepth(1))
+	assertEqual(buf.String(), `{"context.keyType:0":1,"context.sensitiveKey:0":"[REDACTED]","fn":"func()","user":{"name":"alice","roles":"..."}}`)
+
+	buf.Reset()
+	reg.DumpJSON(r, &buf, DumpKeyFunc(func(k interface{}) string { return fmt.Sprintf("%T", k) }))
+	if !strings.Contains(buf.String(), `"string":`) {
+		t.Errorf("Key function not used: %s", buf.String())
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"errors"
+	"net/http"
+)
+
+var (
+	// ErrRequestNotRegistered is returned when no values were stored for
+	// a request.
+	ErrRequestNotRegistered = errors.New("context: request not registered")
+	// ErrKeyNotFound is returned when no value is stored for a key in a
+	// registered request.
+	ErrKeyNotFound = errors.New("context: key not found")
+)
+
+// GetE returns a value stored for a given key in a given request. If there
+// is no value, the error is ErrRequestNotRegistered when nothing was stored
+// for the request at all, and ErrKeyNotFound otherwise.
+func (reg *Registry) GetE(r *http.Request, key interface{}) (interface{}, error) {
+	return getE(reg, r, key)
+}
+
+// GetE returns a value stored for a given key in a given request. If there
+// is no value, the error is ErrRequestNotRegistered when nothing was stored
+// for the request at all, and ErrKeyNotFound otherwise.
+func GetE(r *http.Request, key interface{}) (interface{}, error) {
+	return getE(DefaultStore(), r, key)
+}
+
+func getE(s Store, r *http.Request, key interface{}) (interface{}, error) {
+	if value, ok := s.GetOk(r, key); ok {
+		return value, nil
+	}
+	if rawValues(s, r) == nil {
+		return nil, ErrRequestNotRegistered
+	}
+	return nil, ErrKeyNotFound
+}
+
+// errorsKey is the key the errors recorded with AddError are stored under.
+type errorsKey struct{}
+
+// AddError records a non-fatal error for a given request, to be retrieved
+// later with Errors. Nil errors are ignored.
+func (reg *Registry) AddError(r *http.Request, err error) {
+	if err == nil {
+		return
+	}
+	reg.Update(r, errorsKey{}, func(old interface{}) interface{} {
+		errs, _ := old.([]error)
+		// The slice may be shared with another request, see Copy.
+		return append(errs[:len(errs):len(errs)], err)
+	})
+}
+
+// Errors returns the errors recorded for a given request with AddError, in
+// the order they were added.
+func (reg *Registry) Errors(r *http.Request) []error {
+	errs, _ := reg.Get(r, errorsKey{}).([]error)
+	return append([]error(nil), errs...)
+}
+
+// HasErrors reports whether errors were recorded for a given request with
+// AddError.
+func (reg *Registry) HasErrors(r *http.Request) bool {
+	errs, _ := reg.Get(r, errorsKey{}).([]error)
+	return len(errs) > 0
+}
+
+// AddError records a non-fatal error for a given request, to be retrieved
+// later with Errors. Nil errors are ignored.
+//
+// It lets middleware report problems that don't stop the request, so that
+// a single handler can log or render all of them at the end.
+func AddError(r *http.Request, err error) {
+	defaultRegistry("AddError").AddError(r, err)
+}
+
+// Errors returns the errors recorded for a given request with AddError, in
+// the order they were added.
+func Errors(r *http.Request) []error {
+	return defaultRegistry("Errors").Errors(r)
+}
+
+// HasErrors reports whether errors were recorded for a given request with
+// AddError.
+func HasErrors(r *http.Request) bool {
+	return defaultRegistry("HasErrors").HasErrors(r)
+}
@@ -0,0 +1,111 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestGetE(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+
+	value, err := GetE(r, key1)
+	assertEqual(value, nil)
+	assertEqual(err, ErrRequestNotRegistered)
+
+	Set(r, key1, "1")
+	value, err = GetE(r, key1)
+	assertEqual(value, "1")
+	assertEqual(err, nil)
+
+	value, err = GetE(r, key2)
+	assertEqual(value, nil)
+	assertEqual(err, ErrKeyNotFound)
+
+	// nil values are found.
+	Set(r, key2, nil)
+	value, err = GetE(r, key2)
+	assertEqual(value, nil)
+	assertEqual(err, nil)
+
+	// Lazy values aren't computed to look a key up.
+	computed := false
+	SetLazy(r, key1, func() interface{} {
+		computed = true
+		return "1"
+	})
+	Delete(r, key2)
+	_, err = GetE(r, key2)
+	assertEqual(err, ErrKeyNotFound)
+	NewNamespace(r, "ns").Clear()
+	assertEqual(computed, false)
+}
+
+func TestAddError(t *testing.T) {
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+
+	if HasErrors(r) || len(Errors(r)) != 0 {
+		t.Error("Expected no errors")
+	}
+
+	err1, err2 := errors.New("1"), errors.New("2")
+	AddError(r, err1)
+	AddError(r, nil)
+	AddError(r, err2)
+
+	errs := Errors(r)
+	if !HasErrors(r) || len(errs) != 2 || errs[0] != err1 || errs[1] != err2 {
+		t.Errorf("Unexpected errors %v.", errs)
+	}
+
+	// Errors returns a copy.
+	errs[0] = nil
+	if Errors(r)[0] != err1 {
+		t.Error("Errors returned the stored slice")
+	}
+}
+
+func TestAddErrorCopied(t *testing.T) {
+	a, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	b, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(a)
+	defer Clear(b)
+
+	check := func(r *http.Request, want error) {
+		t.Helper()
+		if got := Errors(r); len(got) != 4 || got[3] != want {
+			t.Errorf("Expected %v last, got %v.", want, got)
+		}
+	}
+
+	// Errors with spare capacity, copied to b.
+	errs := make([]error, 0, 8)
+	errs = append(errs, errors.New("1"), errors.New("2"), errors.New("3"))
+	Set(a, errorsKey{}, errs)
+	Copy(b, a, errorsKey{})
+	errA, errB := errors.New("a4"), errors.New("b4")
+	AddError(a, errA)
+	AddError(b, errB)
+	check(a, errA)
+	check(b, errB)
+
+	// The same slice stored for both requests.
+	Set(a, errorsKey{}, errs)
+	Set(b, errorsKey{}, errs)
+	AddError(a, errA)
+	AddError(b, errB)
+	check(a, errA)
+	check(b, errB)
+}
@@ -0,0 +1,59 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"expvar"
+	"strconv"
+	"sync"
+)
+
+var expvarOnce sync.Once
+
+// EnableExpvar publishes the store statistics as expvar variables:
+//
+//	context.requests           requests with stored values
+//	context.values             values stored across all requests
+//	context.values_per_request histogram of values stored per request
+//	context.sets               calls storing values
+//	context.gets               calls reading values
+//	context.clears             calls to Clear
+//	context.purged             requests removed by purging
+//	context.purges             purge runs
+//	context.max_values         most values held by a single request
+//
+// The histogram buckets are powers of two: "1" counts requests with one
+// value, "2" requests with 2 or 3 values, "4" requests with 4 to 7 values,
+// and so on. Calling EnableExpvar more than once has no effect.
+func EnableExpvar() {
+	expvarOnce.Do(func() {
+		publish := func(name string, fn func(s StoreStats) interface{}) {
+			expvar.Publish(name, expvar.Func(func() interface{} {
+				return fn(Stats())
+			}))
+		}
+		publish("context.requests", func(s StoreStats) interface{} { return s.Requests })
+		publish("context.values", func(s StoreStats) interface{} { return s.Values })
+		publish("context.sets", func(s StoreStats) interface{} { return s.Sets })
+		publish("context.gets", func(s StoreStats) interface{} { return s.Gets })
+		publish("context.clears", func(s StoreStats) interface{} { return s.Clears })
+		publish("context.purged", func(s StoreStats) interface{} { return s.Purged })
+		publish("context.purges", func(s StoreStats) interface{} { return s.Purges })
+		publish("context.max_values", func(s StoreStats) interface{} { return s.MaxValues })
+		expvar.Publish("context.values_per_request", expvar.Func(func() interface{} {
+			return defaultRegistry("EnableExpvar").valuesHistogram()
+		}))
+	})
+}
+
+// valuesHistogram returns the amount of requests per power of two bucket
+// of stored values.
+func (reg *Registry) valuesHistogram() map[string]int {
+	h := make(map[string]int)
+	for bucket, n := range reg.Stats().ValuesPerRequest {
+		h[strconv.Itoa(bucket)] = n
+	}
+	return h
+}
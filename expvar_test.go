@@ -0,0 +1,40 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"testing"
+)
+
+func TestEnableExpvar(t *testing.T) {
+	r1, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	r2, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r1)
+	defer Clear(r2)
+
+	Set(r1, key1, "1")
+	Set(r2, key1, "1")
+	Set(r2, key2, "2")
+	Set(r2, "three", "3")
+
+	EnableExpvar()
+	EnableExpvar()
+
+	v := expvar.Get("context.requests")
+	if v == nil || v.String() != "2" {
+		t.Errorf("Expected 2 requests, got %v.", v)
+	}
+
+	var h map[string]int
+	if err := json.Unmarshal([]byte(expvar.Get("context.values_per_request").String()), &h); err != nil {
+		t.Fatal(err)
+	}
+	if len(h) != 2 || h["1"] != 1 || h["2"] != 1 {
+		t.Errorf("Unexpected histogram %v.", h)
+	}
+}
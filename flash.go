@@ -0,0 +1,62 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// flashesKey is the key the messages added with AddFlash are stored under.
+type flashesKey struct{}
+
+// AddFlash adds a flash message for a given request. Flash messages are
+// read once: Flashes returns them and removes them.
+func (reg *Registry) AddFlash(r *http.Request, msg interface{}) {
+	reg.Update(r, flashesKey{}, func(old interface{}) interface{} {
+		msgs, _ := old.([]interface{})
+		// The slice may be shared with another request.
+		return append(msgs[:len(msgs):len(msgs)], msg)
+	})
+}
+
+// Flashes returns the flash messages added for a given request, in the
+// order they were added, and removes them. Removing them is refused for
+// frozen requests, see Freeze: they're returned and kept.
+func (reg *Registry) Flashes(r *http.Request) []interface{} {
+	atomic.AddUint64(&reg.counters.gets, 1)
+	reg.lock()
+	r = reg.resolve(r)
+	msgs, _ := reg.data[r][flashesKey{}].([]interface{})
+	if msgs == nil {
+		reg.unlock()
+		return nil
+	}
+	if err := reg.checkWrite(r, flashesKey{}); err != nil {
+		reg.unlock()
+		reg.refuse(r, err)
+		return msgs
+	}
+	reg.del(r, flashesKey{}, "Delete")
+	reg.publish(r)
+	reg.unlock()
+	return msgs
+}
+
+// AddFlash adds a flash message for a given request. Flash messages are
+// read once: Flashes returns them and removes them.
+//
+// Unlike session flashes, they only live as long as the request, which is
+// enough to pass messages between handlers of the same request.
+func AddFlash(r *http.Request, msg interface{}) {
+	defaultRegistry("AddFlash").AddFlash(r, msg)
+}
+
+// Flashes returns the flash messages added for a given request, in the
+// order they were added, and removes them. Removing them is refused for
+// frozen requests, see Freeze: they're returned and kept.
+func Flashes(r *http.Request) []interface{} {
+	return defaultRegistry("Flashes").Flashes(r)
+}
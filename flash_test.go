@@ -0,0 +1,64 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestFlashes(t *testing.T) {
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+
+	if msgs := Flashes(r); msgs != nil {
+		t.Errorf("Expected no flashes, got %v.", msgs)
+	}
+
+	AddFlash(r, "saved")
+	AddFlash(r, 42)
+	if msgs := Flashes(r); !reflect.DeepEqual(msgs, []interface{}{"saved", 42}) {
+		t.Errorf("Unexpected flashes %v.", msgs)
+	}
+	// Reading consumes them.
+	if msgs := Flashes(r); msgs != nil {
+		t.Errorf("Expected no flashes, got %v.", msgs)
+	}
+}
+
+func TestFlashesWrites(t *testing.T) {
+	var refused []error
+	reg := New(WithHistory(), WithRefusedWrites(func(r *http.Request, err error) {
+		refused = append(refused, err)
+	}))
+	r1, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	r2, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer reg.Clear(r1)
+	defer reg.Clear(r2)
+
+	// Messages shared by two requests are added separately.
+	msgs := make([]interface{}, 1, 4)
+	reg.Set(r1, flashesKey{}, msgs)
+	reg.Set(r2, flashesKey{}, msgs)
+	reg.AddFlash(r1, "one")
+	reg.AddFlash(r2, "two")
+	if got := reg.Flashes(r1); len(got) != 2 || got[1] != "one" {
+		t.Errorf("Unexpected flashes %v.", got)
+	}
+	if history := reg.History(r1); history[len(history)-1].Op != "Delete" {
+		t.Errorf("Unexpected history %+v.", history)
+	}
+
+	// Frozen requests keep them.
+	reg.Freeze(r2)
+	reg.Flashes(r2)
+	if got := reg.Flashes(r2); len(got) != 2 || got[1] != "two" {
+		t.Errorf("Unexpected flashes %v.", got)
+	}
+	if len(refused) != 2 || refused[0] != ErrFrozen {
+		t.Errorf("Expected ErrFrozen, got %v.", refused)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// errPanicked is returned to the callers sharing a call to Do when the
+// function panics.
+var errPanicked = errors.New("context: function passed to Do panicked")
+
+// flight is an in-flight call to Do.
+type flight struct {
+	wg   sync.WaitGroup
+	val  interface{}
+	err  error
+	dups int
+}
+
+// Do calls fn for a given key in a given request, making sure only one
+// call is in flight at a time: concurrent calls for the same key wait for
+// the first one to complete and receive its result. shared reports
+// whether the result was given to several callers.
+//
+// Unlike Memoize, the result isn't kept once the call completes. fn runs
+// without the registry locked, so it may use it. If fn panics, the panic
+// is propagated to its caller and the others receive an error.
+func (reg *Registry) Do(r *http.Request, key interface{}, fn func() (interface{}, error)) (v interface{}, err error, shared bool) {
+	reg.lock()
+	r = reg.resolve(r)
+	if f, ok := reg.flights[r][key]; ok {
+		f.dups++
+		reg.unlock()
+		f.wg.Wait()
+		return f.val, f.err, true
+	}
+	f := &flight{err: errPanicked}
+	f.wg.Add(1)
+	if reg.flights[r] == nil {
+		reg.flights[r] = make(map[interface{}]*flight)
+	}
+	reg.flights[r][key] = f
+	reg.unlock()
+
+	defer func() {
+		reg.lock()
+		if reg.flights[r][key] == f {
+			delete(reg.flights[r], key)
+			if len(reg.flights[r]) == 0 {
+				delete(reg.flights, r)
+			}
+		}
+		shared = f.dups > 0
+		reg.unlock()
+		f.wg.Done()
+	}()
+	f.val, f.err = fn()
+	return f.val, f.err, false
+}
+
+// Do calls fn for a given key in a given request, making sure only one
+// call is in flight at a time: concurrent calls for the same key wait for
+// the first one to complete and receive its result. shared reports
+// whether the result was given to several callers.
+//
+// Unlike Memoize, the result isn't kept once the call completes. fn runs
+// without the context locked, so it may call functions from this package.
+// If fn panics, the panic is propagated to its caller and the others
+// receive an error.
+func Do(r *http.Request, key interface{}, fn func() (interface{}, error)) (v interface{}, err error, shared bool) {
+	return defaultRegistry("Do").Do(r, key, fn)
+}
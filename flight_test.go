@@ -0,0 +1,96 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDo(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var calls int32
+	fn := func() (interface{}, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+		}
+		<-release
+		return "result", nil
+	}
+
+	var wg sync.WaitGroup
+	results := make(chan bool, 5)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		v, err, shared := Do(r, key1, fn)
+		assertEqual(v, "result")
+		assertEqual(err, nil)
+		results <- shared
+	}()
+	<-started
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, _, shared := Do(r, key1, fn)
+			assertEqual(v, "result")
+			results <- shared
+		}()
+	}
+	// Wait for the duplicate calls to be registered before releasing.
+	for {
+		builtin.mutex.RLock()
+		dups := builtin.flights[r][key1].dups
+		builtin.mutex.RUnlock()
+		if dups == 4 {
+			break
+		}
+	}
+	close(release)
+	wg.Wait()
+	close(results)
+	for shared := range results {
+		assertEqual(shared, true)
+	}
+	assertEqual(atomic.LoadInt32(&calls), int32(1))
+
+	// The result isn't kept.
+	_, _, shared := Do(r, key1, fn)
+	assertEqual(shared, false)
+	assertEqual(atomic.LoadInt32(&calls), int32(2))
+}
+
+func TestDoPanic(t *testing.T) {
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected the panic to be propagated")
+			}
+		}()
+		Do(r, key1, func() (interface{}, error) { panic("boom") })
+	}()
+
+	// The call was cleaned up.
+	v, err, _ := Do(r, key1, func() (interface{}, error) { return 1, nil })
+	if v != 1 || err != nil {
+		t.Errorf("Unexpected result %v, %v.", v, err)
+	}
+}
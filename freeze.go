@@ -0,0 +1,87 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrFrozen is the error of the writes to a request frozen with Freeze.
+var ErrFrozen = errors.New("context: request is frozen")
+
+// WithRefusedWrites makes the registry report the refused writes to fn,
+// like HandleRefusedWrites.
+func WithRefusedWrites(fn func(r *http.Request, err error)) Option {
+	return func(reg *Registry) {
+		reg.refused = fn
+	}
+}
+
+// HandleRefusedWrites sets how writes refused because the request is
+// frozen, the key immutable, or by an interceptor, are handled. By default
+// they panic with ErrFrozen, an error wrapping ErrImmutable, or the error
+// of the interceptor, see RegisterInterceptor. With a non-nil fn, they are
+// ignored instead, and reported to fn, which is called without the lock
+// held. Passing nil restores the default.
+func (reg *Registry) HandleRefusedWrites(fn func(r *http.Request, err error)) {
+	reg.lock()
+	reg.refused = fn
+	reg.unlock()
+}
+
+// HandleRefusedWrites sets how writes refused because the request is
+// frozen, the key immutable, or by an interceptor, are handled. See
+// (*Registry).HandleRefusedWrites for details.
+func HandleRefusedWrites(fn func(r *http.Request, err error)) {
+	defaultRegistry("HandleRefusedWrites").HandleRefusedWrites(fn)
+}
+
+// Freeze makes the values of a given request read-only until it's
+// cleared: Set, SetWithTTL, SetLazy, SetFuture, SetIfAbsent, Swap, Update,
+// Delete and the bulk deletions such as ClearExcept are refused, see
+// HandleRefusedWrites. It lets frameworks lock the values down once
+// middleware ran, so that handlers can only read them.
+//
+// Caches such as GetOrCompute, Memoize, Forget, Resolve and Do still work,
+// as do OnClear and Clear.
+func (reg *Registry) Freeze(r *http.Request) {
+	reg.lock()
+	r = reg.resolve(r)
+	if reg.register(r) {
+		reg.frozen[r] = true
+	}
+	reg.unlock()
+}
+
+// Freeze makes the values of a given request read-only until it's
+// cleared. See (*Registry).Freeze for details.
+func Freeze(r *http.Request) {
+	defaultRegistry("Freeze").Freeze(r)
+}
+
+// IsFrozen reports whether a given request was frozen with Freeze.
+func (reg *Registry) IsFrozen(r *http.Request) bool {
+	reg.rlock()
+	defer reg.mutex.RUnlock()
+	return reg.frozen[reg.resolve(r)]
+}
+
+// IsFrozen reports whether a given request was frozen with Freeze.
+func IsFrozen(r *http.Request) bool {
+	return defaultRegistry("IsFrozen").IsFrozen(r)
+}
+
+// refuse handles a refused write. It must be called without the lock
+// held.
+func (reg *Registry) refuse(r *http.Request, err error) {
+	reg.rlock()
+	fn := reg.refused
+	reg.mutex.RUnlock()
+	if fn == nil {
+		panic(err)
+	}
+	fn(r, err)
+}
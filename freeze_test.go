@@ -0,0 +1,75 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestFreeze(t *testing.T) {
+	var refused []error
+	reg := New(WithRefusedWrites(func(r *http.Request, err error) {
+		refused = append(refused, err)
+	}))
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	reg.Set(r, key1, "1")
+	reg.Freeze(r)
+	if !reg.IsFrozen(r) {
+		t.Fatal("Request not frozen")
+	}
+
+	reg.Set(r, key1, "2")
+	reg.Set(r, key2, "2")
+	reg.Delete(r, key1)
+	if reg.SetIfAbsent(r, key2, "2") {
+		t.Error("SetIfAbsent stored a value")
+	}
+	reg.Update(r, key1, func(interface{}) interface{} { return "2" })
+	reg.ClearExcept(r)
+	if len(refused) != 6 {
+		t.Errorf("Expected 6 refused writes, got %v.", refused)
+	}
+	for _, err := range refused {
+		if err != ErrFrozen {
+			t.Errorf("Expected ErrFrozen, got %v.", err)
+		}
+	}
+	if v := reg.Get(r, key1); v != "1" {
+		t.Errorf("Expected 1, got %v.", v)
+	}
+	if v := reg.GetOrCompute(r, key2, func() interface{} { return "cached" }); v != "cached" {
+		t.Errorf("Expected cached, got %v.", v)
+	}
+
+	reg.Clear(r)
+	if reg.IsFrozen(r) {
+		t.Error("Request still frozen after Clear")
+	}
+	reg.Set(r, key1, "2")
+	reg.Clear(r)
+}
+
+func TestFreezePanics(t *testing.T) {
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+	Freeze(r)
+	defer func() {
+		if err, _ := recover().(error); !errors.Is(err, ErrFrozen) {
+			t.Errorf("Expected an ErrFrozen panic, got %v.", err)
+		}
+	}()
+	Set(r, key1, "1")
+}
+
+func TestFreezeSetOnce(t *testing.T) {
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+	Freeze(r)
+	if err := SetOnce(r, key1, "1"); err != ErrFrozen {
+		t.Errorf("Expected ErrFrozen, got %v.", err)
+	}
+}
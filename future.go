@@ -0,0 +1,134 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	gocontext "context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// future is a value stored with SetFuture.
+type future struct {
+	once sync.Once
+	done chan struct{}
+	val  interface{}
+}
+
+// resolve sets the value of the future, the first time it's called.
+func (f *future) resolve(val interface{}) {
+	f.once.Do(func() {
+		f.val = val
+		close(f.done)
+	})
+}
+
+// resolved reports whether the value of the future is set.
+func (f *future) resolved() bool {
+	select {
+	case <-f.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// value returns the value of the future, or nil if it's not resolved.
+func (f *future) value() interface{} {
+	select {
+	case <-f.done:
+		return f.val
+	default:
+		return nil
+	}
+}
+
+// SetFuture stores a value for a given key in a given request, to be
+// provided later by calling resolve, typically from a goroutine started
+// by the handler. Only the first call to resolve has an effect.
+//
+// GetAwait waits for the value to be resolved. Other functions reading it
+// don't wait, and see nil until then.
+//
+// The value passed to resolve goes through the validators and interceptors
+// of the key. If it's refused, resolve has no effect, see
+// HandleRefusedWrites.
+func (reg *Registry) SetFuture(r *http.Request, key interface{}) (resolve func(interface{})) {
+	f := &future{done: make(chan struct{})}
+	atomic.AddUint64(&reg.counters.sets, 1)
+	reg.lock()
+	r = reg.resolve(r)
+	if err := reg.checkWrite(r, key); err != nil {
+		reg.unlock()
+		reg.refuse(r, err)
+		return func(interface{}) {}
+	}
+	reg.set(r, key, f)
+	reg.unlock()
+	return func(val interface{}) {
+		if f.resolved() {
+			return
+		}
+		reg.lock()
+		val, err := beforeSet(r, key, val)
+		reg.unlock()
+		if err != nil {
+			reg.refuse(r, err)
+			return
+		}
+		f.resolve(val)
+	}
+}
+
+// GetAwait returns the value stored for a given key in a given request.
+// If it was stored with SetFuture, GetAwait waits for it to be resolved
+// or for ctx to be done, in which case the error is ctx.Err(). If no value
+// is stored, the error is ErrKeyNotFound.
+func (reg *Registry) GetAwait(ctx gocontext.Context, r *http.Request, key interface{}) (interface{}, error) {
+	atomic.AddUint64(&reg.counters.gets, 1)
+	reg.rlock()
+	r = reg.resolve(r)
+	value, ok := reg.data[r][key]
+	if ok && reg.expired(r, key) {
+		ok = false
+	}
+	reg.mutex.RUnlock()
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	f, ok := value.(*future)
+	if !ok {
+		return force(value), nil
+	}
+	select {
+	case <-f.done:
+		return f.val, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// SetFuture stores a value for a given key in a given request, to be
+// provided later by calling resolve, typically from a goroutine started
+// by the handler. Only the first call to resolve has an effect.
+//
+// GetAwait waits for the value to be resolved. Other functions reading it
+// don't wait, and see nil until then.
+//
+// The value passed to resolve goes through the validators and interceptors
+// of the key. If it's refused, resolve has no effect, see
+// HandleRefusedWrites.
+func SetFuture(r *http.Request, key interface{}) (resolve func(interface{})) {
+	return defaultRegistry("SetFuture").SetFuture(r, key)
+}
+
+// GetAwait returns the value stored for a given key in a given request.
+// If it was stored with SetFuture, GetAwait waits for it to be resolved
+// or for ctx to be done, in which case the error is ctx.Err(). If no value
+// is stored, the error is ErrKeyNotFound.
+func GetAwait(ctx gocontext.Context, r *http.Request, key interface{}) (interface{}, error) {
+	return defaultRegistry("GetAwait").GetAwait(ctx, r, key)
+}
@@ -0,0 +1,53 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	gocontext "context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSetFuture(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+	ctx := gocontext.Background()
+
+	resolve := SetFuture(r, key1)
+	assertEqual(Get(r, key1), nil)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		resolve("done")
+		resolve("ignored")
+	}()
+	val, err := GetAwait(ctx, r, key1)
+	assertEqual(val, "done")
+	assertEqual(err, nil)
+	assertEqual(Get(r, key1), "done")
+
+	// Plain and missing values.
+	Set(r, key2, "plain")
+	val, err = GetAwait(ctx, r, key2)
+	assertEqual(val, "plain")
+	assertEqual(err, nil)
+	Delete(r, key2)
+	_, err = GetAwait(ctx, r, key2)
+	assertEqual(err, ErrKeyNotFound)
+
+	// Timeout.
+	SetFuture(r, key2)
+	ctx, cancel := gocontext.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = GetAwait(ctx, r, key2)
+	assertEqual(err, gocontext.DeadlineExceeded)
+}
@@ -0,0 +1,53 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"time"
+)
+
+// getAs returns the value stored for a given key in a given request, if
+// it's of type T.
+func getAs[T any](r *http.Request, key interface{}) (T, bool) {
+	val, ok := GetOk(r, key)
+	if ok {
+		var t T
+		t, ok = val.(T)
+		return t, ok
+	}
+	var zero T
+	return zero, false
+}
+
+// GetString returns a string stored for a given key in a given request.
+// ok is false if no value is stored or if it isn't a string.
+func GetString(r *http.Request, key interface{}) (val string, ok bool) {
+	return getAs[string](r, key)
+}
+
+// GetInt returns an int stored for a given key in a given request.
+// ok is false if no value is stored or if it isn't an int.
+func GetInt(r *http.Request, key interface{}) (val int, ok bool) {
+	return getAs[int](r, key)
+}
+
+// GetInt64 returns an int64 stored for a given key in a given request.
+// ok is false if no value is stored or if it isn't an int64.
+func GetInt64(r *http.Request, key interface{}) (val int64, ok bool) {
+	return getAs[int64](r, key)
+}
+
+// GetBool returns a bool stored for a given key in a given request.
+// ok is false if no value is stored or if it isn't a bool.
+func GetBool(r *http.Request, key interface{}) (val bool, ok bool) {
+	return getAs[bool](r, key)
+}
+
+// GetTime returns a time.Time stored for a given key in a given request.
+// ok is false if no value is stored or if it isn't a time.Time.
+func GetTime(r *http.Request, key interface{}) (val time.Time, ok bool) {
+	return getAs[time.Time](r, key)
+}
@@ -0,0 +1,58 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestGetters(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+
+	now := time.Now()
+	Set(r, "string", "foo")
+	Set(r, "int", 1)
+	Set(r, "int64", int64(2))
+	Set(r, "bool", true)
+	Set(r, "time", now)
+
+	s, ok := GetString(r, "string")
+	assertEqual(s, "foo")
+	assertEqual(ok, true)
+	i, ok := GetInt(r, "int")
+	assertEqual(i, 1)
+	assertEqual(ok, true)
+	i64, ok := GetInt64(r, "int64")
+	assertEqual(i64, int64(2))
+	assertEqual(ok, true)
+	b, ok := GetBool(r, "bool")
+	assertEqual(b, true)
+	assertEqual(ok, true)
+	tm, ok := GetTime(r, "time")
+	assertEqual(tm, now)
+	assertEqual(ok, true)
+
+	// Wrong type.
+	s, ok = GetString(r, "int")
+	assertEqual(s, "")
+	assertEqual(ok, false)
+	i64, ok = GetInt64(r, "int")
+	assertEqual(i64, int64(0))
+	assertEqual(ok, false)
+
+	// Missing key.
+	b, ok = GetBool(r, "missing")
+	assertEqual(b, false)
+	assertEqual(ok, false)
+}
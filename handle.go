@@ -0,0 +1,81 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// Context is a handle to the values stored for a single request, returned
+// by Handle. Its methods behave like the package functions for the request.
+//
+// A Context must not be used after the request is cleared: like the
+// package functions, Set would register the request again.
+type Context struct {
+	reg *Registry
+	r   *http.Request
+}
+
+// Handle returns a handle to the values stored for a given request,
+// registering the request if needed.
+func (reg *Registry) Handle(r *http.Request) *Context {
+	reg.lock()
+	r = reg.resolve(r)
+	reg.register(r)
+	reg.unlock()
+	return &Context{reg: reg, r: r}
+}
+
+// Handle returns a handle to the values stored for a given request,
+// registering the request if needed.
+func Handle(r *http.Request) *Context {
+	return defaultRegistry("Handle").Handle(r)
+}
+
+// Set stores a value for a given key. Like the package function, it's
+// refused for frozen requests and immutable keys, and runs the validators
+// and interceptors of the key.
+func (c *Context) Set(key, val interface{}) {
+	atomic.AddUint64(&c.reg.counters.sets, 1)
+	c.reg.lock()
+	val, err := c.reg.checkSet(c.r, key, val)
+	if err != nil {
+		c.reg.unlock()
+		c.reg.refuse(c.r, err)
+		return
+	}
+	c.reg.set(c.r, key, val)
+	task := c.reg.tracing(c.r)
+	c.reg.unlock()
+	task.log(key, val)
+}
+
+// Get returns a value stored for a given key, or its global default.
+func (c *Context) Get(key interface{}) interface{} {
+	return c.reg.Get(c.r, key)
+}
+
+// GetOk returns stored value and presence state like multi-value return of map access.
+// The global default of the key set with SetGlobalDefault counts as present.
+func (c *Context) GetOk(key interface{}) (interface{}, bool) {
+	return c.reg.GetOk(c.r, key)
+}
+
+// Delete removes a value stored for a given key. Like the package
+// function, it's refused for frozen requests and immutable keys.
+func (c *Context) Delete(key interface{}) {
+	c.reg.lock()
+	if err := c.reg.checkWrite(c.r, key); err != nil {
+		c.reg.unlock()
+		c.reg.refuse(c.r, err)
+		return
+	}
+	if c.reg.data[c.r] != nil {
+		c.reg.del(c.r, key, "Delete")
+		c.reg.publish(c.r)
+	}
+	c.reg.unlock()
+}
@@ -0,0 +1,85 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestHandle(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+
+	Set(r, key1, "1")
+	c := Handle(r)
+	assertEqual(c.Get(key1), "1")
+
+	c.Set(key2, "2")
+	assertEqual(Get(r, key2), "2")
+
+	value, ok := c.GetOk(key2)
+	assertEqual(value, "2")
+	assertEqual(ok, true)
+
+	c.Delete(key1)
+	assertEqual(Get(r, key1), nil)
+	_, ok = c.GetOk(key1)
+	assertEqual(ok, false)
+
+	// Global defaults apply, like for the package functions.
+	reg := New()
+	reg.SetGlobalDefault(key1, "default")
+	c = reg.Handle(r)
+	defer reg.Clear(r)
+	assertEqual(c.Get(key1), "default")
+	value, ok = c.GetOk(key1)
+	assertEqual(value, "default")
+	assertEqual(ok, true)
+}
+
+func TestHandleWrites(t *testing.T) {
+	var refused []error
+	reg := New(WithHistory(), WithInsertionOrder(), WithRefusedWrites(func(r *http.Request, err error) {
+		refused = append(refused, err)
+	}))
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer reg.Clear(r)
+
+	// Writes are recorded and ordered like those of the registry.
+	c := reg.Handle(r)
+	c.Set(key2, "2")
+	c.Set(key1, "1")
+	c.Delete(key2)
+	c.Set(key2, "2")
+	if history := reg.History(r); len(history) != 4 || history[2].Op != "Delete" {
+		t.Errorf("Unexpected history %+v.", history)
+	}
+	var keys []interface{}
+	reg.Range(r, func(key, val interface{}) bool {
+		keys = append(keys, key)
+		return true
+	})
+	if len(keys) != 2 || keys[0] != key1 || keys[1] != key2 {
+		t.Errorf("Unexpected keys %v.", keys)
+	}
+
+	// Writes to frozen requests are refused.
+	reg.Freeze(r)
+	c.Set(key1, "2")
+	c.Delete(key1)
+	if len(refused) != 2 || refused[0] != ErrFrozen || refused[1] != ErrFrozen {
+		t.Errorf("Expected 2 ErrFrozen, got %v.", refused)
+	}
+	if v := c.Get(key1); v != "1" {
+		t.Errorf("Expected 1, got %v.", v)
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"time"
+)
+
+// HandlerOptions configures the handler returned by ClearHandlerWithOptions.
+type HandlerOptions struct {
+	// RecoverPanics makes the handler recover from panics in the wrapped
+	// handler and reply with a 500 error, instead of letting the panic go
+	// on. http.ErrAbortHandler is never recovered.
+	RecoverPanics bool
+	// OnPanic, if set, is called when the wrapped handler panics, with the
+	// panic value and the values stored for the request at the time.
+	// Sensitive values are redacted, see MarkSensitive.
+	OnPanic func(r *http.Request, err interface{}, values map[interface{}]interface{})
+	// BeforeClear, if set, is called before clearing a request that still
+	// has stored values, typically to log which keys were left behind.
+	BeforeClear func(r *http.Request, report ClearReport)
+	// AfterResponse, if set, is called once the wrapped handler returned
+	// and before the request is cleared, with all the values stored for
+	// it, sensitive ones redacted. It's meant for access logs and metrics
+	// exporters.
+	AfterResponse func(r *http.Request, values map[interface{}]interface{})
+	// KeepKeys lists keys whose values survive the end of the request:
+	// the handler calls ClearExcept instead of Clear when it's not empty.
+	// Requests for which ClearExcept is refused, because they're frozen or
+	// hold immutable values that aren't kept, are cleared instead.
+	// Whatever consumes the kept values must clear the request, or leave
+	// it to Purge.
+	KeepKeys []interface{}
+}
+
+// ClearReport describes the values left in a request when it's cleared.
+type ClearReport struct {
+	// Path is the URL path of the request.
+	Path string
+	// Age is the time spent in the wrapped handler.
+	Age time.Duration
+	// Keys are the keys still stored for the request.
+	Keys []interface{}
+}
+
+// ClearHandlerWithOptions wraps an http.Handler and clears request values
+// at the end of a request lifetime, like ClearHandler, with additional
+// behavior configured by opts.
+func ClearHandlerWithOptions(h http.Handler, opts HandlerOptions) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		defer func() {
+			if opts.AfterResponse != nil {
+				opts.AfterResponse(r, Redact(GetAll(r)))
+			}
+			if opts.BeforeClear != nil {
+				reportLeftovers(r, start, opts.KeepKeys, opts.BeforeClear)
+			}
+			reg := defaultRegistry("ClearHandlerWithOptions")
+			if len(opts.KeepKeys) == 0 || !reg.tryClearExcept(r, opts.KeepKeys...) {
+				Clear(r)
+			}
+		}()
+		defer func() {
+			err := recover()
+			if err == nil {
+				return
+			}
+			if opts.OnPanic != nil {
+				opts.OnPanic(r, err, Redact(GetAll(r)))
+			}
+			if !opts.RecoverPanics || err == http.ErrAbortHandler {
+				panic(err)
+			}
+			http.Error(w, http.StatusText(http.StatusInternalServerError),
+				http.StatusInternalServerError)
+		}()
+		h.ServeHTTP(w, r)
+	})
+}
+
+// reportLeftovers calls fn with the keys stored for a request, other than
+// the kept ones, if any.
+func reportLeftovers(r *http.Request, start time.Time, keep []interface{}, fn func(*http.Request, ClearReport)) {
+	values := rawValues(DefaultStore(), r)
+	for _, k := range keep {
+		delete(values, k)
+	}
+	if len(values) == 0 {
+		return
+	}
+	report := ClearReport{Age: time.Since(start)}
+	if r.URL != nil {
+		report.Path = r.URL.Path
+	}
+	for k := range values {
+		report.Keys = append(report.Keys, k)
+	}
+	fn(r, report)
+}
@@ -0,0 +1,154 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClearHandlerWithOptions(t *testing.T) {
+	var (
+		gotErr    interface{}
+		gotValues map[interface{}]interface{}
+	)
+	panicking := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Set(r, key1, "1")
+		panic("boom")
+	})
+	onPanic := func(r *http.Request, err interface{}, values map[interface{}]interface{}) {
+		gotErr, gotValues = err, values
+	}
+
+	// RecoverPanics
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	rec := httptest.NewRecorder()
+	h := ClearHandlerWithOptions(panicking, HandlerOptions{RecoverPanics: true, OnPanic: onPanic})
+	h.ServeHTTP(rec, r)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d.", rec.Code)
+	}
+	if gotErr != "boom" || gotValues[key1] != "1" {
+		t.Errorf("Unexpected OnPanic arguments %v, %v.", gotErr, gotValues)
+	}
+	if _, ok := GetAllOk(r); ok {
+		t.Error("Request wasn't cleared")
+	}
+
+	// Panics go on without RecoverPanics.
+	r, _ = http.NewRequest("GET", "http://localhost:8080/", nil)
+	h = ClearHandlerWithOptions(panicking, HandlerOptions{})
+	func() {
+		defer func() {
+			if err := recover(); err != "boom" {
+				t.Errorf("Expected panic boom, got %v.", err)
+			}
+		}()
+		h.ServeHTTP(httptest.NewRecorder(), r)
+	}()
+	if _, ok := GetAllOk(r); ok {
+		t.Error("Request wasn't cleared")
+	}
+}
+
+func TestClearHandlerBeforeClear(t *testing.T) {
+	var reports []ClearReport
+	opts := HandlerOptions{
+		BeforeClear: func(r *http.Request, report ClearReport) {
+			reports = append(reports, report)
+		},
+	}
+
+	h := ClearHandlerWithOptions(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/leaky" {
+			Set(r, key1, "1")
+		}
+	}), opts)
+
+	r, _ := http.NewRequest("GET", "http://localhost:8080/clean", nil)
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	r, _ = http.NewRequest("GET", "http://localhost:8080/leaky", nil)
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	if len(reports) != 1 {
+		t.Fatalf("Expected 1 report, got %d.", len(reports))
+	}
+	if reports[0].Path != "/leaky" || len(reports[0].Keys) != 1 || reports[0].Keys[0] != key1 {
+		t.Errorf("Unexpected report %+v.", reports[0])
+	}
+	if _, ok := GetAllOk(r); ok {
+		t.Error("Request wasn't cleared")
+	}
+}
+
+func TestClearHandlerKeepKeys(t *testing.T) {
+	var reports []ClearReport
+	opts := HandlerOptions{
+		BeforeClear: func(r *http.Request, report ClearReport) {
+			reports = append(reports, report)
+		},
+		KeepKeys: []interface{}{key2},
+	}
+
+	h := ClearHandlerWithOptions(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Set(r, key1, "1")
+		Set(r, key2, "2")
+	}), opts)
+
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	if Get(r, key1) != nil || Get(r, key2) != "2" {
+		t.Errorf("Unexpected values %v.", GetAll(r))
+	}
+	// Kept keys aren't reported as leftovers.
+	if len(reports) != 1 || len(reports[0].Keys) != 1 || reports[0].Keys[0] != key1 {
+		t.Errorf("Unexpected reports %+v.", reports)
+	}
+
+	// Frozen requests are cleared entirely.
+	h = ClearHandlerWithOptions(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Set(r, key1, "1")
+		Set(r, key2, "2")
+		Freeze(r)
+	}), opts)
+	r, _ = http.NewRequest("GET", "http://localhost:8080/", nil)
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	if _, ok := GetAllOk(r); ok {
+		t.Error("Request wasn't cleared")
+	}
+}
+
+func TestClearHandlerAfterResponse(t *testing.T) {
+	var got map[interface{}]interface{}
+	opts := HandlerOptions{
+		AfterResponse: func(r *http.Request, values map[interface{}]interface{}) {
+			got = values
+		},
+		RecoverPanics: true,
+	}
+
+	h := ClearHandlerWithOptions(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Set(r, key1, "1")
+		if r.URL.Path == "/panic" {
+			panic("boom")
+		}
+	}), opts)
+
+	for _, path := range []string{"/", "/panic"} {
+		got = nil
+		r, _ := http.NewRequest("GET", "http://localhost:8080"+path, nil)
+		h.ServeHTTP(httptest.NewRecorder(), r)
+		if len(got) != 1 || got[key1] != "1" {
+			t.Errorf("Unexpected values %v for %s.", got, path)
+		}
+		if _, ok := GetAllOk(r); ok {
+			t.Errorf("Request %s wasn't cleared", path)
+		}
+	}
+}
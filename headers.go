@@ -0,0 +1,26 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+)
+
+// ExtractHeaders wraps an http.Handler and stores the values of request
+// headers before calling it. mapping maps header names, such as
+// "X-Request-Id", to the keys the header values are stored under. Headers
+// missing from the request are skipped.
+//
+// It's the inbound counterpart of Transport.
+func ExtractHeaders(next http.Handler, mapping map[string]interface{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for header, key := range mapping {
+			if value := r.Header.Get(header); value != "" {
+				Set(r, key, value)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,30 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExtractHeaders(t *testing.T) {
+	var values map[interface{}]interface{}
+	h := ExtractHeaders(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		values = GetAll(r)
+	}), map[string]interface{}{
+		"X-Request-Id": key1,
+		"X-Tenant":     key2,
+	})
+
+	r := httptest.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+	r.Header.Set("X-Request-Id", "abc")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	if len(values) != 1 || values[key1] != "abc" {
+		t.Errorf("Unexpected values %v.", values)
+	}
+}
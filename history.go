@@ -0,0 +1,110 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Mutation describes a change of the values of a request, recorded when
+// history is enabled.
+type Mutation struct {
+	// Op is "Set", "Delete", "Expire" for values removed once their TTL
+	// elapsed, or "PopScope" for values restored or removed by PopScope.
+	Op  string
+	Key interface{}
+	// Value is the value stored by Set, or Redacted for sensitive keys.
+	Value interface{}
+	Time  time.Time
+	// Caller is the frame of the first caller outside of this package.
+	Caller runtime.Frame
+}
+
+// WithHistory enables history for the registry, like RecordHistory.
+func WithHistory() Option {
+	return func(reg *Registry) {
+		reg.history = make(map[*http.Request][]Mutation)
+	}
+}
+
+// RecordHistory enables or disables history: when enabled, the values
+// stored and deleted for each request are logged, along with their caller,
+// and returned by History. It's meant for debugging, to find out which
+// code overwrote a value.
+//
+// Disabling history forgets the mutations recorded so far.
+func (reg *Registry) RecordHistory(enabled bool) {
+	reg.lock()
+	switch {
+	case !enabled:
+		reg.history = nil
+	case reg.history == nil:
+		reg.history = make(map[*http.Request][]Mutation)
+	}
+	reg.unlock()
+}
+
+// RecordHistory enables or disables history for the default store. See
+// (*Registry).RecordHistory for details.
+func RecordHistory(enabled bool) {
+	defaultRegistry("RecordHistory").RecordHistory(enabled)
+}
+
+// History returns the mutations of a given request recorded since history
+// was enabled, oldest first. The history of a request is removed with its
+// values.
+func (reg *Registry) History(r *http.Request) []Mutation {
+	reg.rlock()
+	defer reg.mutex.RUnlock()
+	return append([]Mutation(nil), reg.history[reg.resolve(r)]...)
+}
+
+// History returns the mutations of a given request recorded since history
+// was enabled, oldest first. See (*Registry).RecordHistory.
+func History(r *http.Request) []Mutation {
+	return defaultRegistry("History").History(r)
+}
+
+// record appends a mutation to the history of a given request and updates
+// the origin of its key, if enabled. It must be called with the lock held.
+func (reg *Registry) record(r *http.Request, op string, key, val interface{}) {
+	if reg.history == nil && reg.origins == nil {
+		return
+	}
+	frame := caller()
+	if reg.history != nil {
+		reg.history[r] = append(reg.history[r], Mutation{
+			Op:     op,
+			Key:    key,
+			Value:  redactValue(key, val),
+			Time:   reg.now(r),
+			Caller: frame,
+		})
+	}
+	if reg.origins != nil {
+		reg.setOrigin(r, op, key, frame)
+	}
+}
+
+// packagePrefix prefixes the names of the functions of this package.
+const packagePrefix = "github.com/gorilla/context."
+
+// caller returns the frame of the first caller outside of this package,
+// not counting its tests.
+func caller() runtime.Frame {
+	pc := make([]uintptr, 32)
+	n := runtime.Callers(2, pc)
+	frames := runtime.CallersFrames(pc[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, packagePrefix) ||
+			strings.HasSuffix(frame.File, "_test.go") || !more {
+			return frame
+		}
+	}
+}
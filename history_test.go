@@ -0,0 +1,53 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistory(t *testing.T) {
+	reg := New(WithHistory())
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+
+	reg.Set(r, key1, "1")
+	reg.SetWithTTL(r, key1, "2", time.Minute)
+	reg.Delete(r, key1)
+	reg.Update(r, key2, func(interface{}) interface{} { return "3" })
+
+	h := reg.History(r)
+	if len(h) != 4 {
+		t.Fatalf("Expected 4 mutations, got %+v.", h)
+	}
+	for i, want := range []Mutation{
+		{Op: "Set", Key: key1, Value: "1"},
+		{Op: "Set", Key: key1, Value: "2"},
+		{Op: "Delete", Key: key1},
+		{Op: "Set", Key: key2, Value: "3"},
+	} {
+		m := h[i]
+		if m.Op != want.Op || m.Key != want.Key || m.Value != want.Value || m.Time.IsZero() {
+			t.Errorf("Mutation %d: expected %+v, got %+v.", i, want, m)
+		}
+		if !strings.HasSuffix(m.Caller.Function, "TestHistory") || !strings.HasSuffix(m.Caller.File, "history_test.go") {
+			t.Errorf("Mutation %d: unexpected caller %+v.", i, m.Caller)
+		}
+	}
+
+	reg.Clear(r)
+	if h := reg.History(r); len(h) != 0 {
+		t.Errorf("History wasn't cleared: %+v.", h)
+	}
+
+	reg.RecordHistory(false)
+	reg.Set(r, key1, "1")
+	if h := reg.History(r); len(h) != 0 {
+		t.Errorf("History recorded while disabled: %+v.", h)
+	}
+	reg.Clear(r)
+}
@@ -0,0 +1,60 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// WithIdlePurge makes Purge and the janitor remove requests based on the
+// time since they were last accessed, rather than since they were first
+// stored. Storing or reading values, and Touch, count as accesses.
+//
+// It keeps long-lived requests, such as streaming ones, from being removed
+// while they're in use, at the cost of recording the time on each access.
+func WithIdlePurge() Option {
+	return func(reg *Registry) {
+		reg.idle = true
+	}
+}
+
+// Touch records an access to a given request, for registries created with
+// WithIdlePurge. It has no effect on unregistered requests, and on other
+// registries.
+func (reg *Registry) Touch(r *http.Request) {
+	reg.rlock()
+	reg.touch(reg.resolve(r))
+	reg.mutex.RUnlock()
+}
+
+// Touch records an access to a given request, for registries created with
+// WithIdlePurge. It has no effect on unregistered requests, and on other
+// registries.
+func Touch(r *http.Request) {
+	defaultRegistry("Touch").Touch(r)
+}
+
+// touch records an access to a given resolved request. It must be called
+// with the lock held, for reading at least.
+func (reg *Registry) touch(r *http.Request) {
+	if p := reg.access[r]; p != nil {
+		atomic.StoreInt64(p, reg.now(r).Unix())
+	}
+}
+
+// lastActive returns the Unix time in seconds Purge compares to its limit
+// for a given request, on the default clock. It must be called with the
+// lock held.
+func (reg *Registry) lastActive(r *http.Request) int64 {
+	t := reg.datat[r]
+	if p := reg.access[r]; p != nil {
+		t = atomic.LoadInt64(p)
+	}
+	if c := reg.clocks[r]; c != nil {
+		t -= c.Now().Unix() - now().Unix()
+	}
+	return t
+}
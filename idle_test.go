@@ -0,0 +1,62 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestIdlePurge(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	for _, opts := range [][]Option{{WithIdlePurge()}, {WithIdlePurge(), WithCopyOnWrite()}} {
+		reg := New(opts...)
+		active, _ := http.NewRequest("GET", "http://localhost:8080/active", nil)
+		touched, _ := http.NewRequest("GET", "http://localhost:8080/touched", nil)
+		idle, _ := http.NewRequest("GET", "http://localhost:8080/idle", nil)
+		for _, r := range []*http.Request{active, touched, idle} {
+			reg.Set(r, key1, "1")
+		}
+		age := func() {
+			reg.mutex.Lock()
+			for r, p := range reg.access {
+				*p -= 120
+				reg.datat[r] -= 120
+			}
+			reg.mutex.Unlock()
+		}
+
+		age()
+		reg.Get(active, key1)
+		reg.Touch(touched)
+		assertEqual(reg.Purge(60), 1)
+		assertEqual(reg.Get(idle, key1), nil)
+		assertEqual(reg.Get(active, key1), "1")
+		assertEqual(reg.Get(touched, key1), "1")
+
+		age()
+		assertEqual(reg.Purge(60), 2)
+		assertEqual(len(reg.access), 0)
+	}
+}
+
+func TestTouchWithoutIdlePurge(t *testing.T) {
+	reg := New()
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	reg.Set(r, key1, "1")
+	reg.mutex.Lock()
+	reg.datat[r] -= 120
+	reg.mutex.Unlock()
+
+	reg.Touch(r)
+	if n := reg.Purge(60); n != 1 {
+		t.Errorf("Expected the request to be purged by age, got %d.", n)
+	}
+}
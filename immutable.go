@@ -0,0 +1,83 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// ErrAlreadySet is returned by SetOnce when a value is already stored.
+	ErrAlreadySet = errors.New("context: value already set")
+	// ErrImmutable is wrapped by the panics of the functions changing the
+	// value of an immutable key, see MarkImmutable.
+	ErrImmutable = errors.New("context: key is immutable")
+)
+
+// immutable holds the keys marked with MarkImmutable.
+var immutable sync.Map
+
+// MarkImmutable marks keys whose values can't be changed once stored, such
+// as the authenticated principal, so that they can't be overwritten by
+// later middleware. Set, SetWithTTL, SetLazy, SetFuture, Swap, Update,
+// Delete and the bulk deletions such as ClearExcept are refused for a key
+// that has a value: they panic with an error wrapping ErrImmutable, unless
+// HandleRefusedWrites is used. Clearing the request still removes it.
+//
+// Immutable keys apply to all registries.
+func MarkImmutable(keys ...interface{}) {
+	for _, k := range keys {
+		immutable.Store(k, struct{}{})
+	}
+}
+
+// SetOnce stores a value for a given key in a given request, unless a
+// value is already stored, in which case it returns ErrAlreadySet. It
+// returns ErrFrozen for frozen requests, see Freeze.
+func (reg *Registry) SetOnce(r *http.Request, key, val interface{}) error {
+	reg.lock()
+	defer reg.unlock()
+	r = reg.resolve(r)
+	if reg.frozen[r] {
+		return ErrFrozen
+	}
+	if _, ok := reg.data[r][key]; ok && !reg.expired(r, key) {
+		return ErrAlreadySet
+	}
+	val, err := beforeSet(r, key, val)
+	if err != nil {
+		return err
+	}
+	atomic.AddUint64(&reg.counters.sets, 1)
+	reg.set(r, key, val)
+	return nil
+}
+
+// SetOnce stores a value for a given key in a given request, unless a
+// value is already stored, in which case it returns ErrAlreadySet. It
+// returns ErrFrozen for frozen requests, see Freeze.
+func SetOnce(r *http.Request, key, val interface{}) error {
+	return defaultRegistry("SetOnce").SetOnce(r, key, val)
+}
+
+// checkWrite returns ErrFrozen if a given resolved request is frozen, or
+// an error wrapping ErrImmutable if a given key is immutable and has a
+// value in the request. It must be called with the lock held.
+func (reg *Registry) checkWrite(r *http.Request, key interface{}) error {
+	if reg.frozen[r] {
+		return ErrFrozen
+	}
+	if _, ok := immutable.Load(key); !ok {
+		return nil
+	}
+	if _, ok := reg.data[r][key]; !ok || reg.expired(r, key) {
+		return nil
+	}
+	return fmt.Errorf("%w: %v", ErrImmutable, key)
+}
@@ -0,0 +1,79 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type immutableKey int
+
+const principalIDKey immutableKey = 0
+
+func TestSetOnce(t *testing.T) {
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+	if err := SetOnce(r, key1, "1"); err != nil {
+		t.Errorf("Unexpected error %v.", err)
+	}
+	if err := SetOnce(r, key1, "2"); err != ErrAlreadySet {
+		t.Errorf("Expected ErrAlreadySet, got %v.", err)
+	}
+	if v := Get(r, key1); v != "1" {
+		t.Errorf("Expected 1, got %v.", v)
+	}
+}
+
+func TestMarkImmutable(t *testing.T) {
+	MarkImmutable(principalIDKey)
+	reg := New()
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+
+	assertPanics := func(name string, fn func()) {
+		t.Helper()
+		defer func() {
+			err, _ := recover().(error)
+			if !errors.Is(err, ErrImmutable) {
+				t.Errorf("%s: expected an ErrImmutable panic, got %v.", name, err)
+			}
+		}()
+		fn()
+	}
+
+	// The first write is allowed.
+	reg.Set(r, principalIDKey, "alice")
+
+	assertPanics("Set", func() { reg.Set(r, principalIDKey, "mallory") })
+	assertPanics("SetWithTTL", func() { reg.SetWithTTL(r, principalIDKey, "mallory", time.Minute) })
+	assertPanics("SetLazy", func() { reg.SetLazy(r, principalIDKey, func() interface{} { return "mallory" }) })
+	assertPanics("SetFuture", func() { reg.SetFuture(r, principalIDKey) })
+	assertPanics("Swap", func() { reg.Swap(r, principalIDKey, "mallory") })
+	assertPanics("Update", func() { reg.Update(r, principalIDKey, func(interface{}) interface{} { return "mallory" }) })
+	assertPanics("Delete", func() { reg.Delete(r, principalIDKey) })
+	assertPanics("ClearExcept", func() { reg.ClearExcept(r) })
+	assertPanics("DeleteMatching", func() {
+		reg.DeleteMatching(r, func(key, val interface{}) bool { return true })
+	})
+
+	// Keeping the immutable key is allowed.
+	reg.Set(r, key1, "1")
+	reg.ClearExcept(r, principalIDKey)
+	if v := reg.Get(r, key1); v != nil {
+		t.Errorf("Expected nil, got %v.", v)
+	}
+
+	// The registry isn't left locked, and the value is unchanged.
+	if v := reg.Get(r, principalIDKey); v != "alice" {
+		t.Errorf("Expected alice, got %v.", v)
+	}
+
+	// Clearing removes immutable values.
+	reg.Clear(r)
+	reg.Set(r, principalIDKey, "bob")
+	reg.Clear(r)
+}
@@ -0,0 +1,117 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"sync"
+)
+
+// Interceptor holds functions called on the writes of a key, registered
+// with RegisterInterceptor. Any of them may be nil.
+type Interceptor struct {
+	// BeforeSet is called with a value about to be stored, and returns
+	// the value to store instead, such as a normalized one. Returning an
+	// error refuses the write, like writes to a frozen request, see
+	// HandleRefusedWrites; SetOnce and Merge return it instead. It may run
+	// while the registry is locked and must not use it.
+	//
+	// It's called for the results of GetOrCompute, and for the values of
+	// SetLazy and SetFuture once they're computed or resolved: reading a
+	// refused SetLazy value panics with the error. It's not called for the
+	// results cached by Memoize.
+	BeforeSet func(r *http.Request, key, val interface{}) (interface{}, error)
+	// AfterSet is called after a value is stored. It's not called for
+	// SetLazy and SetFuture, whose values are not computed yet.
+	AfterSet func(r *http.Request, key, val interface{})
+	// AfterDelete is called after a value is removed by Delete,
+	// DeleteMatching, DeleteByTag or ClearExcept, or reaped once its TTL
+	// elapsed. It's not called when the request is cleared.
+	//
+	// AfterSet and AfterDelete run once the registry is unlocked, before
+	// the function that changed the value returns, so they can read
+	// values, as audit logging does.
+	AfterDelete func(r *http.Request, key interface{})
+}
+
+var (
+	// interceptors holds the []Interceptor of each key, replaced under
+	// interceptorsMutex.
+	interceptors      sync.Map
+	interceptorsMutex sync.Mutex
+)
+
+// RegisterInterceptor registers functions called on the writes of a key,
+// so that validation, normalization or audit logging are enforced in one
+// place rather than at every call site. The interceptors of a key run in
+// the order they were registered, each BeforeSet receiving the value
+// returned by the previous one.
+//
+// Interceptors apply to all registries.
+func RegisterInterceptor(key interface{}, i Interceptor) {
+	interceptorsMutex.Lock()
+	defer interceptorsMutex.Unlock()
+	var is []Interceptor
+	if v, ok := interceptors.Load(key); ok {
+		is = v.([]Interceptor)
+	}
+	interceptors.Store(key, append(is[:len(is):len(is)], i))
+}
+
+// interceptorsOf returns the interceptors of a given key.
+func interceptorsOf(key interface{}) []Interceptor {
+	if v, ok := interceptors.Load(key); ok {
+		return v.([]Interceptor)
+	}
+	return nil
+}
+
+// beforeSet runs the BeforeSet functions of a given key, and returns the
+// value to store.
+func beforeSet(r *http.Request, key, val interface{}) (interface{}, error) {
+	for _, i := range interceptorsOf(key) {
+		if i.BeforeSet == nil {
+			continue
+		}
+		var err error
+		if val, err = i.BeforeSet(r, key, val); err != nil {
+			return nil, err
+		}
+	}
+	return val, nil
+}
+
+// afterSet queues the AfterSet functions of a given key, unless val is not
+// computed yet. It must be called with the lock held.
+func (reg *Registry) afterSet(r *http.Request, key, val interface{}) {
+	switch val.(type) {
+	case *lazy, *future:
+		return
+	}
+	for _, i := range interceptorsOf(key) {
+		if fn := i.AfterSet; fn != nil {
+			reg.pending = append(reg.pending, func() { fn(r, key, val) })
+		}
+	}
+}
+
+// afterDelete queues the AfterDelete functions of a given key. It must be
+// called with the lock held.
+func (reg *Registry) afterDelete(r *http.Request, key interface{}) {
+	for _, i := range interceptorsOf(key) {
+		if fn := i.AfterDelete; fn != nil {
+			reg.pending = append(reg.pending, func() { fn(r, key) })
+		}
+	}
+}
+
+// checkSet is checkWrite followed by beforeSet, returning the value to
+// store. It must be called with the lock held.
+func (reg *Registry) checkSet(r *http.Request, key, val interface{}) (interface{}, error) {
+	if err := reg.checkWrite(r, key); err != nil {
+		return nil, err
+	}
+	return beforeSet(r, key, val)
+}
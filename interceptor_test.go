@@ -0,0 +1,78 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type interceptedKey int
+
+const (
+	emailKey interceptedKey = iota
+	auditedKey
+)
+
+func TestRegisterInterceptor(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		t.Helper()
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	errEmpty := errors.New("empty email")
+	RegisterInterceptor(emailKey, Interceptor{
+		BeforeSet: func(r *http.Request, key, val interface{}) (interface{}, error) {
+			s, _ := val.(string)
+			if s == "" {
+				return nil, errEmpty
+			}
+			return strings.ToLower(s), nil
+		},
+	})
+	reg := New()
+	var log []string
+	// The hooks run unlocked, and can read values.
+	RegisterInterceptor(auditedKey, Interceptor{
+		AfterSet: func(r *http.Request, key, val interface{}) {
+			log = append(log, fmt.Sprintf("set %v", reg.Get(r, key)))
+		},
+		AfterDelete: func(r *http.Request, key interface{}) {
+			_, ok := reg.GetOk(r, key)
+			log = append(log, fmt.Sprintf("delete %v", ok))
+		},
+	})
+
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer reg.Clear(r)
+
+	reg.Set(r, emailKey, "Alice@Example.com")
+	assertEqual(reg.Get(r, emailKey), "alice@example.com")
+	reg.SetMulti(r, map[interface{}]interface{}{emailKey: "BOB@example.com"})
+	assertEqual(reg.Get(r, emailKey), "bob@example.com")
+	assertEqual(reg.SetOnce(r, "other", "x"), nil)
+
+	var refused error
+	reg.HandleRefusedWrites(func(r *http.Request, err error) { refused = err })
+	reg.Set(r, emailKey, "")
+	assertEqual(refused, errEmpty)
+	assertEqual(reg.Get(r, emailKey), "bob@example.com")
+	err := reg.Merge(r, map[interface{}]interface{}{emailKey: "", key1: "1"}, Overwrite)
+	assertEqual(err, errEmpty)
+	assertEqual(reg.Get(r, key1), nil)
+
+	reg.Set(r, auditedKey, 1)
+	reg.Update(r, auditedKey, func(old interface{}) interface{} { return old.(int) + 1 })
+	reg.SetLazy(r, auditedKey, func() interface{} { return 3 })
+	reg.Delete(r, auditedKey)
+	reg.Set(r, auditedKey, 4)
+	reg.Clear(r)
+	assertEqual(strings.Join(log, ", "), "set 1, set 2, delete false, set 4")
+}
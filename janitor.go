@@ -0,0 +1,109 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// PurgeReport describes a sweep run by PurgeOnce.
+type PurgeReport struct {
+	// Time is the time of the sweep, on the default clock.
+	Time time.Time
+	// Purged describes the requests removed, in no particular order.
+	Purged []PurgedRequest
+	// Expired is the amount of values stored with SetWithTTL removed
+	// from the requests that were kept.
+	Expired int
+}
+
+// StartJanitor starts a goroutine that removes request data stored for
+// longer than maxAge, checking every interval. It returns a function that
+// stops the goroutine; calling it more than once is safe.
+//
+// Like Purge, this is a safety net for handlers that are not wrapped by
+// ClearHandler, not a replacement for it. It panics if interval <= 0.
+func (reg *Registry) StartJanitor(interval, maxAge time.Duration) (stop func()) {
+	if interval <= 0 {
+		panic(fmt.Sprintf("context: non-positive janitor interval %v", interval))
+	}
+	done := make(chan struct{})
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				reg.sweep(maxAge, nil)
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// PurgeOnce runs a single sweep of the janitor started with StartJanitor:
+// it removes request data stored for longer than maxAge, and the expired
+// values of the other requests, and reports what was removed. It lets
+// tests, with SetDefaultClock, and operators trigger a sweep when they
+// choose to.
+//
+// OnClear functions of the removed requests are called after their values
+// are gone.
+func (reg *Registry) PurgeOnce(maxAge time.Duration) PurgeReport {
+	var rep PurgeReport
+	reg.sweep(maxAge, &rep)
+	return rep
+}
+
+// sweep removes request data stored for longer than maxAge, and expired
+// values, describing them in rep unless it's nil. It must be called without
+// the lock held.
+func (reg *Registry) sweep(maxAge time.Duration, rep *PurgeReport) {
+	t := now()
+	min := t.Add(-maxAge).Unix()
+	reg.lock()
+	if rep != nil {
+		rep.Time = t
+		for r := range reg.data {
+			if reg.lastActive(r) < min {
+				rep.Purged = append(rep.Purged, reg.purgedRequest(r))
+				continue
+			}
+			at := reg.now(r)
+			for _, exp := range reg.expires[r] {
+				if !at.Before(exp) {
+					rep.Expired++
+				}
+			}
+		}
+	}
+	_, fns := reg.purge(min)
+	reg.unlock()
+	runHooks(fns)
+}
+
+// StartJanitor starts a goroutine that removes request data stored for
+// longer than maxAge, checking every interval. It returns a function that
+// stops the goroutine; calling it more than once is safe.
+//
+// Like Purge, this is a safety net for handlers that are not wrapped by
+// ClearHandler, not a replacement for it. It panics if interval <= 0.
+func StartJanitor(interval, maxAge time.Duration) (stop func()) {
+	return defaultRegistry("StartJanitor").StartJanitor(interval, maxAge)
+}
+
+// PurgeOnce runs a single sweep of the janitor started with StartJanitor:
+// it removes request data stored for longer than maxAge, and the expired
+// values of the other requests, and reports what was removed.
+func PurgeOnce(maxAge time.Duration) PurgeReport {
+	return defaultRegistry("PurgeOnce").PurgeOnce(maxAge)
+}
@@ -0,0 +1,103 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestStartJanitor(t *testing.T) {
+	stale, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	fresh, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(fresh)
+
+	Set(stale, key1, "1")
+	Set(fresh, key1, "1")
+	builtin.mutex.Lock()
+	builtin.datat[stale] -= 120
+	builtin.mutex.Unlock()
+
+	stop := StartJanitor(time.Millisecond, time.Minute)
+	defer stop()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, ok := GetAllOk(stale); !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Janitor didn't purge stale request data")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if Get(fresh, key1) != "1" {
+		t.Error("Janitor purged fresh request data")
+	}
+
+	stop()
+	stop()
+}
+
+func TestStartJanitorInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		func() {
+			defer func() {
+				if err := recover(); err != "context: non-positive janitor interval "+interval.String() {
+					t.Errorf("Expected an interval panic, got %v.", err)
+				}
+			}()
+			StartJanitor(interval, time.Minute)
+		}()
+	}
+}
+
+func TestPurgeOnce(t *testing.T) {
+	c := &fakeClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	SetDefaultClock(c)
+	defer SetDefaultClock(nil)
+
+	reg := New()
+	stale, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	fresh, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer reg.Clear(fresh)
+	reg.Set(stale, key1, "1")
+	reg.Set(stale, key2, "2")
+	cleared := false
+	reg.OnClear(stale, func() { cleared = true })
+
+	c.advance(2 * time.Minute)
+	reg.Set(fresh, key1, "1")
+	reg.SetWithTTL(fresh, key2, "2", time.Second)
+	c.advance(time.Second)
+
+	rep := reg.PurgeOnce(time.Minute)
+	if !rep.Time.Equal(c.Now()) {
+		t.Errorf("Expected time %v, got %v.", c.Now(), rep.Time)
+	}
+	if len(rep.Purged) != 1 || rep.Purged[0].Request != stale || len(rep.Purged[0].Keys) != 2 {
+		t.Errorf("Expected the stale request with 2 keys, got %+v.", rep.Purged)
+	} else if age := rep.Purged[0].Age; age != 121*time.Second {
+		t.Errorf("Expected age 2m1s, got %v.", age)
+	}
+	if rep.Expired != 1 {
+		t.Errorf("Expected 1 expired value, got %d.", rep.Expired)
+	}
+	if !cleared {
+		t.Error("OnClear function not called")
+	}
+	if _, ok := reg.GetOk(stale, key1); ok {
+		t.Error("Stale request not purged")
+	}
+	if reg.Get(fresh, key1) != "1" {
+		t.Error("Fresh request purged")
+	}
+
+	rep = reg.PurgeOnce(time.Minute)
+	if len(rep.Purged) != 0 || rep.Expired != 0 {
+		t.Errorf("Expected an empty report, got %+v.", rep)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package jwtclaims is an example of authentication middleware storing
+// its result with context.SetPrincipal.
+//
+// Handler verifies HS256 JSON Web Tokens sent as bearer tokens, and stores
+// their claims as the principal of the request:
+//
+//	http.Handle("/", context.ClearHandler(jwtclaims.Handler(h, secret)))
+//
+//	func h(w http.ResponseWriter, r *http.Request) {
+//		claims, ok := context.Principal[jwtclaims.Claims](r)
+//		// ...
+//	}
+//
+// It only supports what the example needs; use a full JWT library for
+// other algorithms or claims validation.
+package jwtclaims
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/context"
+)
+
+// Claims are the claims of a token.
+type Claims map[string]interface{}
+
+// Errors returned by Parse.
+var (
+	ErrMalformed = errors.New("jwtclaims: malformed token")
+	ErrSignature = errors.New("jwtclaims: invalid signature")
+	ErrExpired   = errors.New("jwtclaims: token expired")
+)
+
+// Handler wraps an http.Handler, verifies the bearer token of a request
+// with secret and stores its claims with context.SetPrincipal before
+// calling it. Requests without a token are passed through unchanged, and
+// requests with an invalid one are rejected with 401 Unauthorized.
+func Handler(h http.Handler, secret []byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if auth == "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+		token := strings.TrimPrefix(auth, "Bearer ")
+		claims, err := Parse(token, secret)
+		if token == auth || err != nil {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		context.SetPrincipal(r, claims)
+		h.ServeHTTP(w, r)
+	})
+}
+
+// Parse verifies an HS256 token with secret and returns its claims. The
+// exp claim is checked if present.
+func Parse(token string, secret []byte) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decode(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return nil, ErrMalformed
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	if !hmac.Equal(sig, sign(parts[0]+"."+parts[1], secret)) {
+		return nil, ErrSignature
+	}
+	var claims Claims
+	if err := decode(parts[1], &claims); err != nil {
+		return nil, ErrMalformed
+	}
+	if exp, ok := claims["exp"].(float64); ok && time.Now().Unix() >= int64(exp) {
+		return nil, ErrExpired
+	}
+	return claims, nil
+}
+
+// Sign returns an HS256 token holding claims, signed with secret.
+func Sign(claims Claims, secret []byte) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	s := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) +
+		"." + base64.RawURLEncoding.EncodeToString(payload)
+	return s + "." + base64.RawURLEncoding.EncodeToString(sign(s, secret)), nil
+}
+
+// sign returns the HMAC-SHA256 of s.
+func sign(s string, secret []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(s))
+	return mac.Sum(nil)
+}
+
+// decode decodes a base64url encoded JSON segment into v.
+func decode(segment string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
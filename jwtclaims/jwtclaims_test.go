@@ -0,0 +1,71 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jwtclaims
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/context"
+)
+
+var secret = []byte("secret")
+
+func TestParse(t *testing.T) {
+	token, err := Sign(Claims{"sub": "alice"}, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := Parse(token, secret)
+	if err != nil || claims["sub"] != "alice" {
+		t.Errorf("Unexpected claims %v, error %v.", claims, err)
+	}
+
+	if _, err := Parse(token, []byte("other")); err != ErrSignature {
+		t.Errorf("Expected %v, got %v.", ErrSignature, err)
+	}
+	if _, err := Parse("abc", secret); err != ErrMalformed {
+		t.Errorf("Expected %v, got %v.", ErrMalformed, err)
+	}
+	expired, _ := Sign(Claims{"exp": time.Now().Add(-time.Minute).Unix()}, secret)
+	if _, err := Parse(expired, secret); err != ErrExpired {
+		t.Errorf("Expected %v, got %v.", ErrExpired, err)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	var sub interface{}
+	h := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, _ := context.Principal[Claims](r)
+		sub = claims["sub"]
+	}), secret)
+	serve := func(auth string) int {
+		sub = nil
+		r := httptest.NewRequest("GET", "http://localhost:8080/", nil)
+		defer context.Clear(r)
+		if auth != "" {
+			r.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		return rec.Code
+	}
+
+	token, _ := Sign(Claims{"sub": "alice"}, secret)
+	if code := serve("Bearer " + token); code != http.StatusOK || sub != "alice" {
+		t.Errorf("Unexpected status %d, subject %v.", code, sub)
+	}
+	if code := serve(""); code != http.StatusOK || sub != nil {
+		t.Errorf("Unexpected status %d, subject %v.", code, sub)
+	}
+	if code := serve("Bearer invalid"); code != http.StatusUnauthorized {
+		t.Errorf("Expected %d, got %d.", http.StatusUnauthorized, code)
+	}
+	if code := serve(token); code != http.StatusUnauthorized {
+		t.Errorf("Expected %d, got %d.", http.StatusUnauthorized, code)
+	}
+}
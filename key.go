@@ -0,0 +1,46 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+)
+
+// Key is a typed key for values of type T.
+//
+// Values are kept in the same store used by Set and Get, so a Key can be
+// mixed freely with the untyped API. Each call to NewKey returns a distinct
+// key, even when the same name is used twice.
+type Key[T any] struct {
+	name string
+}
+
+// NewKey returns a new key for values of type T. The name is only used
+// for debugging.
+func NewKey[T any](name string) *Key[T] {
+	return &Key[T]{name: name}
+}
+
+// Set stores a value for this key in a given request.
+func (k *Key[T]) Set(r *http.Request, val T) {
+	Set(r, k, val)
+}
+
+// Get returns the value stored for this key in a given request, and whether
+// a value of type T was found.
+func (k *Key[T]) Get(r *http.Request) (T, bool) {
+	if v, ok := GetOk(r, k); ok {
+		if val, ok := v.(T); ok {
+			return val, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// String returns the name the key was created with.
+func (k *Key[T]) String() string {
+	return k.name
+}
@@ -0,0 +1,52 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestKey(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+
+	intKey := NewKey[int]("int")
+	strKey := NewKey[string]("str")
+	otherKey := NewKey[int]("int")
+
+	// Get() before Set()
+	v, ok := intKey.Get(r)
+	assertEqual(v, 0)
+	assertEqual(ok, false)
+
+	// Set() and Get()
+	intKey.Set(r, 42)
+	strKey.Set(r, "foo")
+	v, ok = intKey.Get(r)
+	assertEqual(v, 42)
+	assertEqual(ok, true)
+	s, ok := strKey.Get(r)
+	assertEqual(s, "foo")
+	assertEqual(ok, true)
+
+	// Keys with the same name don't collide.
+	_, ok = otherKey.Get(r)
+	assertEqual(ok, false)
+
+	// Interoperates with the untyped API.
+	assertEqual(Get(r, intKey), 42)
+	Set(r, intKey, "not an int")
+	_, ok = intKey.Get(r)
+	assertEqual(ok, false)
+
+	assertEqual(intKey.String(), "int")
+}
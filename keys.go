@@ -0,0 +1,81 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// Keys returns the keys of the values stored for a given request, in
+// insertion order if the registry was created with WithInsertionOrder, or
+// in no particular order otherwise. Nil is returned for invalid requests.
+func (reg *Registry) Keys(r *http.Request) []interface{} {
+	reg.rlock()
+	defer reg.mutex.RUnlock()
+	r = reg.resolve(r)
+	if reg.data[r] == nil {
+		return nil
+	}
+	all := reg.keysOf(r)
+	keys := all[:0]
+	for _, k := range all {
+		if !reg.expired(r, k) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// SortedKeys returns the keys of the values stored for a given request,
+// sorted by their string form, so that test assertions and debug output
+// are the same from run to run. Keys with the same string form, such as 1
+// and "1", are sorted by type name, then by Go syntax. Keys remaining tied
+// keep their insertion order if the registry was created with
+// WithInsertionOrder. Nil is returned for invalid requests.
+func (reg *Registry) SortedKeys(r *http.Request) []interface{} {
+	keys := reg.Keys(r)
+	sortKeys(keys)
+	return keys
+}
+
+// sortKeys sorts keys by their string form, then by type name, then by
+// Go syntax, which tells apart keys of the same type printed the same by a
+// String method. The sort is stable: the remaining ties, such as distinct
+// pointers to equal values, keep their order.
+func sortKeys(keys []interface{}) {
+	type name struct{ str, typ, gosyntax string }
+	names := make(map[interface{}]name, len(keys))
+	for _, k := range keys {
+		names[k] = name{fmt.Sprint(k), fmt.Sprintf("%T", k), fmt.Sprintf("%#v", k)}
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		a, b := names[keys[i]], names[keys[j]]
+		switch {
+		case a.str != b.str:
+			return a.str < b.str
+		case a.typ != b.typ:
+			return a.typ < b.typ
+		}
+		return a.gosyntax < b.gosyntax
+	})
+}
+
+// Keys returns the keys of the values stored for a given request, in no
+// particular order. Nil is returned for invalid requests.
+func Keys(r *http.Request) []interface{} {
+	return defaultRegistry("Keys").Keys(r)
+}
+
+// SortedKeys returns the keys of the values stored for a given request,
+// sorted by their string form, so that test assertions and debug output
+// are the same from run to run. Keys with the same string form, such as 1
+// and "1", are sorted by type name, then by Go syntax. Keys remaining tied
+// keep their insertion order if the registry was created with
+// WithInsertionOrder. Nil is returned for invalid requests.
+func SortedKeys(r *http.Request) []interface{} {
+	return defaultRegistry("SortedKeys").SortedKeys(r)
+}
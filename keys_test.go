@@ -0,0 +1,54 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestKeys(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		t.Helper()
+		if !reflect.DeepEqual(val, exp) {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+	assertEqual(Keys(r), []interface{}(nil))
+	assertEqual(SortedKeys(r), []interface{}(nil))
+
+	Set(r, "b", 1)
+	Set(r, 1, 2)
+	Set(r, "1", 3)
+	Set(r, "a", 4)
+	assertEqual(len(Keys(r)), 4)
+	assertEqual(SortedKeys(r), []interface{}{1, "1", "a", "b"})
+
+	reg := New(WithInsertionOrder())
+	defer reg.Clear(r)
+	reg.Set(r, "b", 1)
+	reg.Set(r, "a", 2)
+	assertEqual(reg.Keys(r), []interface{}{"b", "a"})
+
+	// Keys printed the same are ordered, and the remaining ties keep
+	// their insertion order.
+	p1, p2 := &sameKey{1}, &sameKey{1}
+	reg.Set(r, sameKey{2}, 3)
+	reg.Set(r, sameKey{1}, 4)
+	reg.Set(r, p2, 5)
+	reg.Set(r, p1, 6)
+	for i := 0; i < 10; i++ {
+		assertEqual(reg.SortedKeys(r), []interface{}{"a", "b", p2, p1, sameKey{1}, sameKey{2}})
+	}
+}
+
+// sameKey is a key type whose values all print the same.
+type sameKey struct{ id int }
+
+func (sameKey) String() string { return "same" }
@@ -0,0 +1,109 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"fmt"
+	"sort"
+	"sync/atomic"
+)
+
+// KeyStat holds the usage counters of a key.
+type KeyStat struct {
+	// Key is the type and string form of the key, such as
+	// "context.userKey:0". Keys with the same form are counted together.
+	Key string
+	// Sets counts the values stored for the key, and Gets the lookups.
+	Sets uint64
+	Gets uint64
+}
+
+// keyCounter holds the counters of a key, updated atomically.
+type keyCounter struct {
+	name string
+	sets uint64
+	gets uint64
+}
+
+// WithKeyStats enables key statistics for the registry, like
+// EnableKeyStats.
+func WithKeyStats() Option {
+	return func(reg *Registry) {
+		reg.keyStats = 1
+	}
+}
+
+// EnableKeyStats enables or disables key statistics: when enabled, the
+// values stored and looked up are counted per key, and returned by
+// KeyStats. It helps finding keys that are never read, or read the most,
+// when auditing middleware.
+//
+// Disabling key statistics resets the counters.
+func (reg *Registry) EnableKeyStats(enabled bool) {
+	if enabled {
+		atomic.StoreInt32(&reg.keyStats, 1)
+		return
+	}
+	atomic.StoreInt32(&reg.keyStats, 0)
+	reg.keyCounters.Range(func(k, _ interface{}) bool {
+		reg.keyCounters.Delete(k)
+		return true
+	})
+}
+
+// EnableKeyStats enables or disables key statistics for the default store.
+// See (*Registry).EnableKeyStats for details.
+func EnableKeyStats(enabled bool) {
+	defaultRegistry("EnableKeyStats").EnableKeyStats(enabled)
+}
+
+// KeyStats returns the usage counters of the keys used since key
+// statistics were enabled, sorted by key.
+func (reg *Registry) KeyStats() []KeyStat {
+	byName := make(map[string]*KeyStat)
+	reg.keyCounters.Range(func(_, v interface{}) bool {
+		c := v.(*keyCounter)
+		s := byName[c.name]
+		if s == nil {
+			s = &KeyStat{Key: c.name}
+			byName[c.name] = s
+		}
+		s.Sets += atomic.LoadUint64(&c.sets)
+		s.Gets += atomic.LoadUint64(&c.gets)
+		return true
+	})
+	stats := make([]KeyStat, 0, len(byName))
+	for _, s := range byName {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Key < stats[j].Key
+	})
+	return stats
+}
+
+// KeyStats returns the usage counters of the keys used in the default
+// store since key statistics were enabled, sorted by key.
+func KeyStats() []KeyStat {
+	return defaultRegistry("KeyStats").KeyStats()
+}
+
+// countKey counts a store, or a lookup if get is set, of a given key when
+// key statistics are enabled.
+func (reg *Registry) countKey(key interface{}, get bool) {
+	if atomic.LoadInt32(&reg.keyStats) == 0 {
+		return
+	}
+	v, ok := reg.keyCounters.Load(key)
+	if !ok {
+		v, _ = reg.keyCounters.LoadOrStore(key, &keyCounter{name: fmt.Sprintf("%T:%v", key, key)})
+	}
+	c := v.(*keyCounter)
+	if get {
+		atomic.AddUint64(&c.gets, 1)
+	} else {
+		atomic.AddUint64(&c.sets, 1)
+	}
+}
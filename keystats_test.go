@@ -0,0 +1,45 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestKeyStats(t *testing.T) {
+	reg := New(WithKeyStats())
+	r1, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	r2, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer reg.Clear(r1)
+	defer reg.Clear(r2)
+
+	reg.Set(r1, key1, "1")
+	reg.Set(r2, key1, "2")
+	reg.Get(r1, key1)
+	reg.GetOk(r2, key2)
+	reg.Set(r1, "name", "3")
+
+	stats := reg.KeyStats()
+	want := []KeyStat{
+		{Key: "context.keyType:0", Sets: 2, Gets: 1},
+		{Key: "context.keyType:1", Gets: 1},
+		{Key: "string:name", Sets: 1},
+	}
+	if len(stats) != len(want) {
+		t.Fatalf("Expected %+v, got %+v.", want, stats)
+	}
+	for i := range want {
+		if stats[i] != want[i] {
+			t.Errorf("Expected %+v, got %+v.", want[i], stats[i])
+		}
+	}
+
+	reg.EnableKeyStats(false)
+	reg.Get(r1, key1)
+	if stats := reg.KeyStats(); len(stats) != 0 {
+		t.Errorf("Unexpected stats %+v after disabling.", stats)
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ErrWrongType is wrapped by the *InvalidValue errors of the writes of a
+// value whose type doesn't match the type registered for its key, see
+// RegisterKeyType.
+var ErrWrongType = errors.New("context: value of the wrong type")
+
+// keyTypes holds the types registered with RegisterKeyType.
+var keyTypes sync.Map
+
+// RegisterKeyType registers the type of the values of a key, so that
+// storing a value of another type is refused like the writes refused by a
+// validator, see RegisterValidator, with an error wrapping ErrWrongType.
+// It catches packages using the same key, typically a string, for values
+// of different types. A nil value is only accepted for interface types,
+// and values of an interface type t must implement it. The check covers
+// every write, including the results of GetOrCompute, SetLazy and
+// SetFuture, see Interceptor.BeforeSet, and the writes through Handle.
+//
+// It panics if a different type was already registered for the key, which
+// reveals such clashes when the packages are initialized.
+func RegisterKeyType(key interface{}, t reflect.Type) {
+	if prev, loaded := keyTypes.LoadOrStore(key, t); loaded {
+		if prev != t {
+			panic(fmt.Sprintf("context: key %v registered with types %v and %v", key, prev, t))
+		}
+		return
+	}
+	RegisterValidator(key, func(val interface{}) error {
+		if !hasType(val, t) {
+			return fmt.Errorf("%w: %T, expected %v", ErrWrongType, val, t)
+		}
+		return nil
+	})
+}
+
+// RegisterKeyTypeOf registers T as the type of the values of a key, like
+// RegisterKeyType:
+//
+//	context.RegisterKeyTypeOf[*User](userKey)
+func RegisterKeyTypeOf[T any](key interface{}) {
+	RegisterKeyType(key, reflect.TypeOf((*T)(nil)).Elem())
+}
+
+// hasType reports whether val can be stored for a key of type t.
+func hasType(val interface{}, t reflect.Type) bool {
+	if t.Kind() == reflect.Interface {
+		return val == nil || reflect.TypeOf(val).Implements(t)
+	}
+	return reflect.TypeOf(val) == t
+}
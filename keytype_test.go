@@ -0,0 +1,74 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+type typedKey int
+
+const (
+	typedIntKey typedKey = iota
+	typedStringerKey
+)
+
+func TestRegisterKeyType(t *testing.T) {
+	RegisterKeyType(typedIntKey, reflect.TypeOf(0))
+	RegisterKeyType(typedIntKey, reflect.TypeOf(0))
+	RegisterKeyTypeOf[fmt.Stringer](typedStringerKey)
+
+	reg := New()
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer reg.Clear(r)
+
+	for _, tc := range []struct {
+		key interface{}
+		val interface{}
+		ok  bool
+	}{
+		{typedIntKey, 1, true},
+		{typedIntKey, "1", false},
+		{typedIntKey, int64(1), false},
+		{typedIntKey, nil, false},
+		{typedStringerKey, stringerValue{1}, true},
+		{typedStringerKey, nil, true},
+		{typedStringerKey, 1, false},
+	} {
+		err := reg.SetChecked(r, tc.key, tc.val)
+		if tc.ok && err != nil {
+			t.Errorf("%v=%#v: unexpected error %v.", tc.key, tc.val, err)
+		}
+		if !tc.ok && !errors.Is(err, ErrWrongType) {
+			t.Errorf("%v=%#v: expected ErrWrongType, got %v.", tc.key, tc.val, err)
+		}
+	}
+
+	// Computed values and writes through Handle are checked too.
+	var refused []error
+	reg.HandleRefusedWrites(func(r *http.Request, err error) {
+		refused = append(refused, err)
+	})
+	reg.Delete(r, typedIntKey)
+	reg.GetOrCompute(r, typedIntKey, func() interface{} { return "1" })
+	reg.Handle(r).Set(typedIntKey, "1")
+	if len(refused) != 2 || !errors.Is(refused[0], ErrWrongType) || !errors.Is(refused[1], ErrWrongType) {
+		t.Errorf("Expected 2 ErrWrongType, got %v.", refused)
+	}
+	if _, ok := reg.GetOk(r, typedIntKey); ok {
+		t.Error("Value of the wrong type stored")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Registering another type didn't panic.")
+		}
+	}()
+	RegisterKeyType(typedIntKey, reflect.TypeOf(""))
+}
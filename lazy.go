@@ -0,0 +1,97 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// lazy is a value stored with SetLazy.
+type lazy struct {
+	once sync.Once
+	fn   func() interface{}
+	// check returns the value to store for the result of fn, or the error
+	// refusing it.
+	check func(val interface{}) (interface{}, error)
+	val   interface{}
+	err   error
+}
+
+// value returns the result of fn, calling it the first time. It panics if
+// the result is refused.
+func (l *lazy) value() interface{} {
+	l.once.Do(func() {
+		l.val, l.err = l.check(l.fn())
+		l.fn, l.check = nil, nil
+	})
+	if l.err != nil {
+		panic(l.err)
+	}
+	return l.val
+}
+
+// force returns the value of v if it was stored with SetLazy or SetFuture,
+// or v.
+func force(v interface{}) interface{} {
+	switch v := v.(type) {
+	case *lazy:
+		return v.value()
+	case *future:
+		return v.value()
+	}
+	return v
+}
+
+// forceAll replaces the values of m stored with SetLazy or SetFuture by
+// their value.
+func forceAll(m map[interface{}]interface{}) {
+	for k, v := range m {
+		switch v.(type) {
+		case *lazy, *future:
+			m[k] = force(v)
+		}
+	}
+}
+
+// SetLazy stores a value for a given key in a given request, computed by
+// fn the first time it's read. fn is called at most once, even when the
+// value is read concurrently, and not at all if it's never read.
+//
+// fn may be called while the registry is locked and must not use it.
+//
+// The result of fn goes through the validators and interceptors of the key
+// once it's computed. If it's refused, reading the value panics with the
+// error, even if HandleRefusedWrites is used.
+func (reg *Registry) SetLazy(r *http.Request, key interface{}, fn func() interface{}) {
+	atomic.AddUint64(&reg.counters.sets, 1)
+	reg.lock()
+	r = reg.resolve(r)
+	if err := reg.checkWrite(r, key); err != nil {
+		reg.unlock()
+		reg.refuse(r, err)
+		return
+	}
+	check := func(val interface{}) (interface{}, error) {
+		return beforeSet(r, key, val)
+	}
+	reg.set(r, key, &lazy{fn: fn, check: check})
+	reg.unlock()
+}
+
+// SetLazy stores a value for a given key in a given request, computed by
+// fn the first time it's read. fn is called at most once, even when the
+// value is read concurrently, and not at all if it's never read.
+//
+// fn may be called while the context is locked and must not call functions
+// from this package.
+//
+// The result of fn goes through the validators and interceptors of the key
+// once it's computed. If it's refused, reading the value panics with the
+// error, even if HandleRefusedWrites is used.
+func SetLazy(r *http.Request, key interface{}, fn func() interface{}) {
+	defaultRegistry("SetLazy").SetLazy(r, key, fn)
+}
@@ -0,0 +1,66 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSetLazy(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+
+	var calls int32
+	SetLazy(r, key1, func() interface{} {
+		atomic.AddInt32(&calls, 1)
+		return "computed"
+	})
+	SetLazy(r, key2, func() interface{} {
+		t.Error("Replaced lazy value was computed")
+		return nil
+	})
+	assertEqual(atomic.LoadInt32(&calls), int32(0))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assertEqual(Get(r, key1), "computed")
+		}()
+	}
+	wg.Wait()
+	assertEqual(atomic.LoadInt32(&calls), int32(1))
+
+	val, ok := GetOk(r, key1)
+	assertEqual(val, "computed")
+	assertEqual(ok, true)
+	assertEqual(Handle(r).Get(key1), "computed")
+
+	// Replacing a lazy value doesn't compute it unless it's returned.
+	Set(r, key2, "plain")
+	assertEqual(Get(r, key2), "plain")
+	Range(r, func(k, v interface{}) bool {
+		if k == key1 {
+			assertEqual(v, "computed")
+		}
+		return true
+	})
+
+	SetLazy(r, key2, func() interface{} { return "lazy" })
+	assertEqual(GetAll(r)[key2], "lazy")
+	old, _ := Swap(r, key2, "swapped")
+	assertEqual(old, "lazy")
+	assertEqual(atomic.LoadInt32(&calls), int32(1))
+}
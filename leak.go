@@ -0,0 +1,125 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"runtime/debug"
+	"time"
+)
+
+// Leak describes a request whose values were not cleared at the end of its
+// lifetime.
+type Leak struct {
+	// Request is the leaked request.
+	Request *http.Request
+	// Keys are the keys still stored for the request.
+	Keys []interface{}
+	// Stack is the stack trace of the call that first stored a value for
+	// the request.
+	Stack []byte
+}
+
+// DetectLeaks enables leak detection: fn is called for every request that
+// still has stored values grace after its context is done. Passing a nil fn
+// disables leak detection.
+//
+// The server cancels the context of a request when its handler returns,
+// so values still stored by then are leaked, typically because the handler
+// wasn't wrapped by ClearHandler. The context is also cancelled when the
+// client goes away, so grace should be longer than the handlers may keep
+// running after that.
+//
+// Leak detection captures a stack trace for every request and is meant for
+// tests and staging environments. Only requests first used after the call
+// are watched.
+func (reg *Registry) DetectLeaks(grace time.Duration, fn func(Leak)) {
+	reg.lock()
+	reg.leakReport = fn
+	reg.leakGrace = grace
+	reg.unlock()
+}
+
+// DetectLeaks enables leak detection: fn is called for every request that
+// still has stored values grace after its context is done. Passing a nil fn
+// disables leak detection.
+//
+// See (*Registry).DetectLeaks for details.
+func DetectLeaks(grace time.Duration, fn func(Leak)) {
+	defaultRegistry("DetectLeaks").DetectLeaks(grace, fn)
+}
+
+// watchLeak starts watching a request for leaks. It must be called with the
+// lock held.
+func (reg *Registry) watchLeak(r *http.Request) {
+	done := r.Context().Done()
+	if done == nil {
+		// The context is never cancelled.
+		return
+	}
+	if reg.stacks[r] == nil {
+		reg.stacks[r] = debug.Stack()
+	}
+	report, grace := reg.leakReport, reg.leakGrace
+	go func() {
+		<-done
+		time.AfterFunc(grace, func() {
+			reg.rlock()
+			values, ok := reg.data[r]
+			// Retained requests are meant to outlive their context.
+			if reg.retains[r] != nil {
+				ok = false
+			}
+			leak := Leak{Request: r, Stack: reg.stacks[r]}
+			for k := range values {
+				leak.Keys = append(leak.Keys, k)
+			}
+			reg.mutex.RUnlock()
+			if ok {
+				report(leak)
+			}
+		})
+	}()
+}
+
+// CaptureStacks makes the registry capture the stack trace of the call
+// that first stores a value for each request, reported by Leaks. Leak
+// detection captures them regardless.
+func (reg *Registry) CaptureStacks(enabled bool) {
+	reg.lock()
+	reg.captureStacks = enabled
+	reg.unlock()
+}
+
+// CaptureStacks makes the default store capture the stack trace of the
+// call that first stores a value for each request, reported by Leaks.
+func CaptureStacks(enabled bool) {
+	defaultRegistry("CaptureStacks").CaptureStacks(enabled)
+}
+
+// Leaks returns all the requests with stored values, as leaks. Unlike
+// DetectLeaks, it doesn't wait for their context to be done: it's meant
+// for tests, at the end of which all requests should have been cleared.
+//
+// Stack is only set for requests first used while stacks were captured.
+func (reg *Registry) Leaks() []Leak {
+	reg.rlock()
+	defer reg.mutex.RUnlock()
+	leaks := make([]Leak, 0, len(reg.data))
+	for r, values := range reg.data {
+		leak := Leak{Request: r, Stack: reg.stacks[r]}
+		for k := range values {
+			leak.Keys = append(leak.Keys, k)
+		}
+		leaks = append(leaks, leak)
+	}
+	return leaks
+}
+
+// Leaks returns all the requests with values in the default store, as
+// leaks. See (*Registry).Leaks for details.
+func Leaks() []Leak {
+	return defaultRegistry("Leaks").Leaks()
+}
@@ -0,0 +1,85 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	gocontext "context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetectLeaks(t *testing.T) {
+	leaks := make(chan Leak, 2)
+	DetectLeaks(time.Millisecond, func(l Leak) { leaks <- l })
+	defer DetectLeaks(0, nil)
+
+	newRequest := func() (*http.Request, gocontext.CancelFunc) {
+		ctx, cancel := gocontext.WithCancel(gocontext.Background())
+		r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+		return r.WithContext(ctx), cancel
+	}
+
+	leaked, cancelLeaked := newRequest()
+	cleared, cancelCleared := newRequest()
+	defer Clear(leaked)
+
+	Set(leaked, key1, "1")
+	Set(cleared, key1, "1")
+	Clear(cleared)
+	cancelCleared()
+	cancelLeaked()
+
+	select {
+	case l := <-leaks:
+		if l.Request != leaked {
+			t.Errorf("Expected leak of %p, got %p.", leaked, l.Request)
+		}
+		if len(l.Keys) != 1 || l.Keys[0] != key1 {
+			t.Errorf("Unexpected leaked keys %v.", l.Keys)
+		}
+		if !strings.Contains(string(l.Stack), "TestDetectLeaks") {
+			t.Errorf("Stack doesn't show the caller:\n%s", l.Stack)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Leak wasn't reported")
+	}
+
+	select {
+	case l := <-leaks:
+		t.Errorf("Unexpected leak of %p.", l.Request)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestLeaks(t *testing.T) {
+	reg := New()
+	reg.CaptureStacks(true)
+	r1, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	r2, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	reg.Set(r1, key1, "1")
+	reg.Set(r2, key1, "1")
+	reg.Clear(r2)
+
+	leaks := reg.Leaks()
+	if len(leaks) != 1 || leaks[0].Request != r1 {
+		t.Fatalf("Unexpected leaks %+v.", leaks)
+	}
+	if len(leaks[0].Keys) != 1 || leaks[0].Keys[0] != key1 {
+		t.Errorf("Unexpected leaked keys %v.", leaks[0].Keys)
+	}
+	if !strings.Contains(string(leaks[0].Stack), "TestLeaks") {
+		t.Errorf("Stack doesn't show the caller:\n%s", leaks[0].Stack)
+	}
+
+	reg.CaptureStacks(false)
+	reg.Set(r2, key1, "1")
+	for _, l := range reg.Leaks() {
+		if l.Request == r2 && l.Stack != nil {
+			t.Error("Stack captured while disabled")
+		}
+	}
+}
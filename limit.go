@@ -0,0 +1,118 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// Policy decides what happens when a value is stored for a new request in
+// a registry holding the maximum amount of requests set by WithMaxEntries.
+type Policy struct {
+	evict bool
+	fn    func(r *http.Request) bool
+}
+
+var (
+	// RejectSet drops the values stored for new requests until existing
+	// ones are cleared.
+	RejectSet = Policy{}
+	// EvictOldest removes the request registered first to make room for
+	// the new one, like Clear: its OnClear functions run with its values
+	// still stored, before the function storing the new request returns.
+	// Values stored for the evicted request meanwhile are dropped, and the
+	// functions it registers with OnClear run too. Requests held with
+	// Retain are not evicted.
+	EvictOldest = Policy{evict: true}
+)
+
+// Callback returns a Policy calling fn with the new request, which evicts
+// the oldest request if fn returns true, like EvictOldest, and rejects the
+// new one otherwise, like RejectSet. fn runs while the registry is locked
+// and must not use it.
+func Callback(fn func(r *http.Request) bool) Policy {
+	return Policy{fn: fn}
+}
+
+// WithMaxEntries caps the amount of requests with stored values to n, and
+// sets the policy applied to new requests past it. It protects the program
+// from leaking requests until it runs out of memory.
+func WithMaxEntries(n int, policy Policy) Option {
+	return func(reg *Registry) {
+		reg.maxEntries = n
+		reg.policy = policy
+	}
+}
+
+// makeRoom applies the policy of the registry for a new request while it's
+// full, and reports whether the request can be registered. It must be
+// called with the lock held.
+func (reg *Registry) makeRoom(r *http.Request) bool {
+	evict := reg.policy.evict
+	if reg.policy.fn != nil {
+		evict = reg.policy.fn(r)
+	}
+	if evict {
+		if oldest := reg.oldest(); oldest != nil {
+			reg.dequeue(oldest)
+			reg.evicting[oldest] = struct{}{}
+			fns := reg.takeHooks(nil, oldest)
+			// OnClear functions can't run with the lock held: the request
+			// is cleared once they ran, when the registry is unlocked,
+			// along with the ones they registered.
+			reg.pending = append(reg.pending, func() {
+				for {
+					runHooks(fns)
+					reg.lock()
+					// Unless it was cleared meanwhile.
+					if _, ok := reg.evicting[oldest]; !ok {
+						reg.unlock()
+						return
+					}
+					if fns = reg.takeHooks(nil, oldest); len(fns) == 0 {
+						reg.clear(oldest)
+						reg.unlock()
+						return
+					}
+					reg.unlock()
+				}
+			})
+			atomic.AddUint64(&reg.counters.evicted, 1)
+			return true
+		}
+	}
+	atomic.AddUint64(&reg.counters.rejected, 1)
+	return false
+}
+
+// enqueue records the registration of a given request. It must be called
+// with the lock held.
+func (reg *Registry) enqueue(r *http.Request) {
+	reg.arrival[r] = reg.arrivals.PushBack(r)
+}
+
+// dequeue removes a given request from the registration order. It must be
+// called with the lock held.
+func (reg *Registry) dequeue(r *http.Request) {
+	if e, ok := reg.arrival[r]; ok {
+		if reg.cursor == e {
+			reg.cursor = e.Next()
+		}
+		reg.arrivals.Remove(e)
+		delete(reg.arrival, r)
+	}
+}
+
+// oldest returns the request registered first, other than retained ones
+// and ones being evicted, or nil. It must be called with the lock held.
+func (reg *Registry) oldest() *http.Request {
+	for e := reg.arrivals.Front(); e != nil; e = e.Next() {
+		if r := e.Value.(*http.Request); reg.retains[r] == nil {
+			return r
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestMaxEntriesReject(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	reg := New(WithMaxEntries(1, RejectSet))
+	r1, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	r2, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+
+	reg.Set(r1, key1, "1")
+	reg.Set(r2, key1, "2")
+	reg.SetWithTTL(r2, key2, "2", 0)
+	reg.OnClear(r2, func() { t.Error("OnClear of a rejected request") })
+	reg.Handle(r2).Set(key1, "dropped")
+	ch, cancel := reg.Watch(r2, key1)
+	cancel()
+	_, ok := <-ch
+	assertEqual(ok, false)
+
+	assertEqual(reg.Get(r1, key1), "1")
+	assertEqual(reg.GetAll(r2) == nil, true)
+	assertEqual(reg.Stats().Rejected, uint64(6))
+
+	// Existing requests can still be updated, and clearing makes room.
+	reg.Set(r1, key2, "1")
+	assertEqual(reg.Get(r1, key2), "1")
+	reg.Clear(r1)
+	reg.Set(r2, key1, "2")
+	assertEqual(reg.Get(r2, key1), "2")
+	reg.Clear(r2)
+}
+
+func TestMaxEntriesEvict(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	reg := New(WithMaxEntries(2, EvictOldest))
+	r1, _ := http.NewRequest("GET", "http://localhost:8080/1", nil)
+	r2, _ := http.NewRequest("GET", "http://localhost:8080/2", nil)
+	r3, _ := http.NewRequest("GET", "http://localhost:8080/3", nil)
+
+	// OnClear functions run before Set returns, and can read the values.
+	var seen interface{}
+	reg.Set(r1, key1, "1")
+	reg.OnClear(r1, func() { seen = reg.Get(r1, key1) })
+	reg.Set(r2, key1, "2")
+
+	reg.Set(r3, key1, "3")
+	assertEqual(seen, "1")
+	assertEqual(reg.Get(r1, key1), nil)
+	assertEqual(reg.Get(r2, key1), "2")
+	assertEqual(reg.Get(r3, key1), "3")
+	assertEqual(reg.Stats().Evicted, uint64(1))
+	assertEqual(reg.Stats().Requests, 2)
+
+	// While a request is evicted, nothing new is stored for it, and the
+	// functions it registers with OnClear run.
+	var late bool
+	reg.OnClear(r2, func() {
+		reg.Set(r2, key2, "dropped")
+		seen = reg.Get(r2, key2)
+		reg.OnClear(r2, func() { late = true })
+	})
+	reg.Set(r1, key1, "1")
+	assertEqual(seen, nil)
+	assertEqual(late, true)
+	assertEqual(reg.Get(r2, key1), nil)
+	assertEqual(reg.Stats().Evicted, uint64(2))
+	assertEqual(reg.Stats().Requests, 2)
+}
+
+func TestMaxEntriesCallback(t *testing.T) {
+	evict := false
+	var seen *http.Request
+	reg := New(WithMaxEntries(1, Callback(func(r *http.Request) bool {
+		seen = r
+		return evict
+	})))
+	r1, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	r2, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+
+	reg.Set(r1, key1, "1")
+	reg.Set(r2, key1, "2")
+	if seen != r2 || reg.Get(r2, key1) != nil {
+		t.Error("Expected the new request to be rejected")
+	}
+	evict = true
+	reg.Set(r2, key1, "2")
+	if reg.Get(r1, key1) != nil || reg.Get(r2, key1) != "2" {
+		t.Error("Expected the old request to be evicted")
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	gocontext "context"
+	"net/http"
+	"time"
+)
+
+// Link makes clone share the values stored for original, so that Set and
+// Get on either request see the same values. It's meant for requests
+// created with r.WithContext() or r.Clone(), which are new *http.Request
+// values that would otherwise start with no values at all.
+//
+// Values already stored for clone are moved to original, along with their
+// expiration, the channels returned by Watch and the requests linked to
+// clone. The link is removed when either request is cleared; clearing
+// clone doesn't clear the values of original.
+func (reg *Registry) Link(original, clone *http.Request) {
+	reg.lock()
+	original = reg.resolve(original)
+	if original != clone {
+		if _, ok := reg.links[clone]; ok {
+			reg.unlink(clone)
+		}
+		if !reg.register(original) {
+			reg.unlock()
+			return
+		}
+		for _, k := range reg.keysOf(clone) {
+			reg.insert(original, k)
+			reg.data[original][k] = reg.data[clone][k]
+			if exp, ok := reg.expires[clone][k]; ok {
+				if reg.expires[original] == nil {
+					reg.expires[original] = make(map[interface{}]time.Time)
+				}
+				reg.expires[original][k] = exp
+			} else {
+				delete(reg.expires[original], k)
+			}
+		}
+		reg.hooks[original] = append(reg.hooks[original], reg.hooks[clone]...)
+		for k, ws := range reg.watchers[clone] {
+			if reg.watchers[original] == nil {
+				reg.watchers[original] = make(map[interface{}][]*watcher)
+			}
+			for _, w := range ws {
+				w.r = original
+			}
+			reg.watchers[original][k] = append(reg.watchers[original][k], ws...)
+		}
+		delete(reg.watchers, clone)
+		// The clones of clone are linked to original instead.
+		clones := reg.clones[clone]
+		delete(reg.clones, clone)
+		for _, c := range clones {
+			reg.links[c] = original
+		}
+		reg.clear(clone)
+		reg.links[clone] = original
+		reg.clones[original] = append(append(reg.clones[original], clones...), clone)
+		reg.publish(original)
+	}
+	reg.unlock()
+}
+
+// Link makes clone share the values stored for original, so that Set and
+// Get on either request see the same values. It's meant for requests
+// created with r.WithContext() or r.Clone(), which are new *http.Request
+// values that would otherwise start with no values at all.
+//
+// Values already stored for clone are moved to original, along with their
+// expiration, the channels returned by Watch and the requests linked to
+// clone. The link is removed when either request is cleared; clearing
+// clone doesn't clear the values of original.
+func Link(original, clone *http.Request) {
+	defaultRegistry("Link").Link(original, clone)
+}
+
+// WithContext returns r.WithContext(ctx), linked to r.
+func WithContext(r *http.Request, ctx gocontext.Context) *http.Request {
+	clone := r.WithContext(ctx)
+	Link(r, clone)
+	return clone
+}
+
+// Clone returns r.Clone(ctx), linked to r.
+func Clone(r *http.Request, ctx gocontext.Context) *http.Request {
+	clone := r.Clone(ctx)
+	Link(r, clone)
+	return clone
+}
+
+// resolve returns the request a given request is linked to, or the request
+// itself. It must be called with the lock held.
+func (reg *Registry) resolve(r *http.Request) *http.Request {
+	if original, ok := reg.links[r]; ok {
+		return original
+	}
+	return r
+}
+
+// unlink removes the link of a clone. It must be called with the lock held.
+func (reg *Registry) unlink(clone *http.Request) {
+	original := reg.links[clone]
+	delete(reg.links, clone)
+	reg.unpublish(clone)
+	c := reg.clones[original]
+	for i := range c {
+		if c[i] == clone {
+			c = append(c[:i], c[i+1:]...)
+			break
+		}
+	}
+	if len(c) == 0 {
+		delete(reg.clones, original)
+	} else {
+		reg.clones[original] = c
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	gocontext "context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestLink(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	Set(r, key1, "1")
+
+	// Values stored on the original are visible on the clone.
+	clone := WithContext(r, gocontext.Background())
+	assertEqual(Get(clone, key1), "1")
+
+	// ...and the other way around.
+	Set(clone, key2, "2")
+	assertEqual(Get(r, key2), "2")
+
+	// Clones of clones resolve to the original.
+	clone2 := Clone(clone, gocontext.Background())
+	assertEqual(Get(clone2, key1), "1")
+	assertEqual(builtin.links[clone2], r)
+
+	// Values stored on a clone before linking are kept.
+	other, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	Set(other, "other", "value")
+	Link(r, other)
+	assertEqual(Get(r, "other"), "value")
+
+	// Clearing a clone only removes the link.
+	Clear(clone)
+	assertEqual(Get(clone, key1), nil)
+	assertEqual(Get(r, key1), "1")
+	assertEqual(len(builtin.clones[r]), 2)
+
+	// Clearing the original removes all links.
+	Clear(r)
+	assertEqual(Get(clone2, key1), nil)
+	assertEqual(len(builtin.links), 0)
+	assertEqual(len(builtin.clones), 0)
+	assertEqual(len(builtin.data), 0)
+}
+
+func TestLinkMovesState(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	reg := New()
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	other, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	otherClone, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer reg.Clear(r)
+	reg.Set(other, key1, "1")
+	reg.SetWithTTL(other, key2, "expired", -time.Second)
+	ch, cancel := reg.Watch(other, key1)
+	reg.Link(other, otherClone)
+
+	reg.Link(r, other)
+	// Expiration is kept.
+	_, ok := reg.GetOk(r, key2)
+	assertEqual(ok, false)
+	// Watchers follow the values.
+	reg.Set(other, key1, "2")
+	assertEqual(<-ch, "2")
+	cancel()
+	_, ok = <-ch
+	assertEqual(ok, false)
+	reg.Set(r, key1, "3")
+	// Clones of the clone are linked to the original.
+	assertEqual(reg.links[otherClone], r)
+	assertEqual(reg.Get(otherClone, key1), "3")
+}
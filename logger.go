@@ -0,0 +1,45 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+)
+
+// loggerKey is the key the logger of a request is stored under.
+type loggerKey struct{}
+
+// defaultLogger holds the logger set with SetDefaultLogger.
+var defaultLogger atomic.Pointer[slog.Logger]
+
+// SetLogger stores the logger of a given request, typically a logger
+// carrying request attributes set by a middleware, so that handlers can
+// retrieve it with Logger.
+func SetLogger(r *http.Request, l *slog.Logger) {
+	Set(r, loggerKey{}, l)
+}
+
+// Logger returns the logger stored for a given request by SetLogger. If
+// none is stored, it returns the logger set with SetDefaultLogger, or
+// slog.Default().
+func Logger(r *http.Request) *slog.Logger {
+	if v, ok := GetOk(r, loggerKey{}); ok {
+		if l, ok := v.(*slog.Logger); ok && l != nil {
+			return l
+		}
+	}
+	if l := defaultLogger.Load(); l != nil {
+		return l
+	}
+	return slog.Default()
+}
+
+// SetDefaultLogger sets the logger Logger returns for the requests with
+// no logger stored. Passing nil restores slog.Default().
+func SetDefaultLogger(l *slog.Logger) {
+	defaultLogger.Store(l)
+}
@@ -0,0 +1,38 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		t.Helper()
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+	assertEqual(Logger(r), slog.Default())
+
+	fallback := slog.New(slog.NewTextHandler(io.Discard, nil))
+	SetDefaultLogger(fallback)
+	defer SetDefaultLogger(nil)
+	assertEqual(Logger(r), fallback)
+
+	l := fallback.With("request", "1")
+	SetLogger(r, l)
+	assertEqual(Logger(r), l)
+
+	SetDefaultLogger(nil)
+	Clear(r)
+	assertEqual(Logger(r), slog.Default())
+}
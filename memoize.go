@@ -0,0 +1,73 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"sync"
+)
+
+// memo is a result cached by Memoize.
+type memo struct {
+	once sync.Once
+	val  interface{}
+	err  error
+}
+
+// Memoize returns the result of fn for a given key in a given request,
+// calling fn only the first time. Later calls return the cached value and
+// error, until the request is cleared or Forget is called.
+//
+// Concurrent calls for the same key wait for the first one to complete.
+// fn runs without the registry locked, so it may use it.
+func (reg *Registry) Memoize(r *http.Request, key interface{}, fn func() (interface{}, error)) (interface{}, error) {
+	reg.lock()
+	r = reg.resolve(r)
+	m := reg.memos[r][key]
+	if m == nil && reg.register(r) {
+		if reg.memos[r] == nil {
+			reg.memos[r] = make(map[interface{}]*memo)
+		}
+		m = new(memo)
+		reg.memos[r][key] = m
+	}
+	reg.unlock()
+	if m == nil {
+		// The registry is full: results aren't cached.
+		return fn()
+	}
+	m.once.Do(func() {
+		m.val, m.err = fn()
+	})
+	return m.val, m.err
+}
+
+// Forget removes the result cached by Memoize for a given key in a given
+// request, so that the next call calls fn again. It's typically used to
+// retry after an error.
+func (reg *Registry) Forget(r *http.Request, key interface{}) {
+	reg.lock()
+	r = reg.resolve(r)
+	delete(reg.memos[r], key)
+	reg.unlock()
+}
+
+// Memoize returns the result of fn for a given key in a given request,
+// calling fn only the first time. Later calls return the cached value and
+// error, until the request is cleared or Forget is called.
+//
+// Concurrent calls for the same key wait for the first one to complete.
+// fn runs without the context locked, so it may call functions from this
+// package.
+func Memoize(r *http.Request, key interface{}, fn func() (interface{}, error)) (interface{}, error) {
+	return defaultRegistry("Memoize").Memoize(r, key, fn)
+}
+
+// Forget removes the result cached by Memoize for a given key in a given
+// request, so that the next call calls fn again. It's typically used to
+// retry after an error.
+func Forget(r *http.Request, key interface{}) {
+	defaultRegistry("Forget").Forget(r, key)
+}
@@ -0,0 +1,94 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestMemoize(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+
+	calls := 0
+	load := func() (interface{}, error) {
+		calls++
+		// fn may use the context.
+		Set(r, key2, "loaded")
+		return "alice", nil
+	}
+	val, err := Memoize(r, key1, load)
+	assertEqual(val, "alice")
+	assertEqual(err, nil)
+	val, _ = Memoize(r, key1, load)
+	assertEqual(val, "alice")
+	assertEqual(calls, 1)
+	assertEqual(Get(r, key2), "loaded")
+
+	// The cached result doesn't collide with a value for the same key.
+	assertEqual(Get(r, key1), nil)
+
+	// Nor is it one of the values of the request, copied with them.
+	assertEqual(len(GetAll(r)), 1)
+	r2, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r2)
+	Copy(r2, r)
+	val, _ = Memoize(r2, key1, func() (interface{}, error) { return "bob", nil })
+	assertEqual(val, "bob")
+
+	// Errors are cached until forgotten.
+	fail := errors.New("fail")
+	_, err = Memoize(r, key2, func() (interface{}, error) { return nil, fail })
+	assertEqual(err, fail)
+	_, err = Memoize(r, key2, func() (interface{}, error) { return "ok", nil })
+	assertEqual(err, fail)
+	Forget(r, key2)
+	val, err = Memoize(r, key2, func() (interface{}, error) { return "ok", nil })
+	assertEqual(val, "ok")
+	assertEqual(err, nil)
+
+	// Results can be forgotten in frozen requests.
+	Freeze(r)
+	Forget(r, key2)
+	val, _ = Memoize(r, key2, func() (interface{}, error) { return "again", nil })
+	assertEqual(val, "again")
+
+	// Cleared with the request.
+	Clear(r)
+	Memoize(r, key1, load)
+	assertEqual(calls, 2)
+}
+
+func TestMemoizeConcurrent(t *testing.T) {
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+
+	var calls int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			Memoize(r, key1, func() (interface{}, error) {
+				atomic.AddInt32(&calls, 1)
+				return nil, nil
+			})
+		}()
+	}
+	wg.Wait()
+	if calls != 1 {
+		t.Errorf("Expected 1 call, got %d.", calls)
+	}
+}
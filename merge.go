@@ -0,0 +1,82 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+)
+
+// ErrConflict is wrapped by the error returned by Merge with the
+// ErrorOnConflict policy when a key already has a value.
+var ErrConflict = errors.New("context: key already has a value")
+
+// MergePolicy tells Merge what to do with keys that already have a value.
+type MergePolicy int
+
+const (
+	// KeepExisting skips the keys that already have a value.
+	KeepExisting MergePolicy = iota
+	// Overwrite replaces the values already stored.
+	Overwrite
+	// ErrorOnConflict stores nothing and returns an error wrapping
+	// ErrConflict if any key already has a value.
+	ErrorOnConflict
+)
+
+// Merge stores values in a given request in a single locked operation,
+// resolving the keys that already have a value with policy. It's meant for
+// populating a request from decoded tokens or upstream baggage.
+//
+// It returns ErrFrozen for frozen requests, an error wrapping ErrImmutable
+// when Overwrite would replace the value of an immutable key, and the
+// errors of interceptors, see RegisterInterceptor. Nothing is stored when
+// an error is returned.
+func (reg *Registry) Merge(r *http.Request, values map[interface{}]interface{}, policy MergePolicy) error {
+	reg.lock()
+	defer reg.unlock()
+	r = reg.resolve(r)
+	if reg.frozen[r] {
+		return ErrFrozen
+	}
+	store := make(map[interface{}]interface{}, len(values))
+	for k, v := range values {
+		if _, ok := reg.data[r][k]; ok && !reg.expired(r, k) {
+			switch policy {
+			case KeepExisting:
+				continue
+			case ErrorOnConflict:
+				return fmt.Errorf("%w: %v", ErrConflict, k)
+			}
+			if err := reg.checkWrite(r, k); err != nil {
+				return err
+			}
+		}
+		v, err := beforeSet(r, k, v)
+		if err != nil {
+			return err
+		}
+		store[k] = v
+	}
+	for k, v := range store {
+		atomic.AddUint64(&reg.counters.sets, 1)
+		reg.set(r, k, v)
+	}
+	return nil
+}
+
+// Merge stores values in a given request in a single locked operation,
+// resolving the keys that already have a value with policy. It's meant for
+// populating a request from decoded tokens or upstream baggage.
+//
+// It returns ErrFrozen for frozen requests, an error wrapping ErrImmutable
+// when Overwrite would replace the value of an immutable key, and the
+// errors of interceptors, see RegisterInterceptor. Nothing is stored when
+// an error is returned.
+func Merge(r *http.Request, values map[interface{}]interface{}, policy MergePolicy) error {
+	return defaultRegistry("Merge").Merge(r, values, policy)
+}
@@ -0,0 +1,46 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		t.Helper()
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+	Set(r, key1, "1")
+
+	err := Merge(r, map[interface{}]interface{}{key1: "a", key2: "b"}, KeepExisting)
+	assertEqual(err, nil)
+	assertEqual(Get(r, key1), "1")
+	assertEqual(Get(r, key2), "b")
+
+	err = Merge(r, map[interface{}]interface{}{key1: "c", "key3": "d"}, ErrorOnConflict)
+	if !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict, got %v.", err)
+	}
+	assertEqual(Get(r, key1), "1")
+	assertEqual(Get(r, "key3"), nil)
+
+	err = Merge(r, map[interface{}]interface{}{key1: "c", "key3": "d"}, Overwrite)
+	assertEqual(err, nil)
+	assertEqual(Get(r, key1), "c")
+	assertEqual(Get(r, "key3"), "d")
+
+	Freeze(r)
+	err = Merge(r, map[interface{}]interface{}{"key4": "e"}, Overwrite)
+	assertEqual(err, ErrFrozen)
+	assertEqual(Get(r, "key4"), nil)
+}
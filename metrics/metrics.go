@@ -0,0 +1,80 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package metrics exports statistics of the gorilla/context store as
+// Prometheus metrics.
+//
+// Register a Collector to alert on request values that are never cleared:
+//
+//	prometheus.MustRegister(metrics.NewCollector())
+package metrics
+
+import (
+	"github.com/gorilla/context"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector is a prometheus.Collector reporting context.Stats().
+type Collector struct {
+	requests *prometheus.Desc
+	values   *prometheus.Desc
+	sets     *prometheus.Desc
+	gets     *prometheus.Desc
+	clears   *prometheus.Desc
+	purged   *prometheus.Desc
+	purges   *prometheus.Desc
+	maxVals  *prometheus.Desc
+	lockWait *prometheus.Desc
+}
+
+// NewCollector returns a new Collector.
+func NewCollector() *Collector {
+	return &Collector{
+		requests: prometheus.NewDesc("gorilla_context_requests",
+			"Number of requests with stored values.", nil, nil),
+		values: prometheus.NewDesc("gorilla_context_values",
+			"Number of values stored across all requests.", nil, nil),
+		sets: prometheus.NewDesc("gorilla_context_sets_total",
+			"Total number of values stored.", nil, nil),
+		gets: prometheus.NewDesc("gorilla_context_gets_total",
+			"Total number of values read.", nil, nil),
+		clears: prometheus.NewDesc("gorilla_context_clears_total",
+			"Total number of requests cleared.", nil, nil),
+		purged: prometheus.NewDesc("gorilla_context_purged_total",
+			"Total number of requests removed by purging.", nil, nil),
+		purges: prometheus.NewDesc("gorilla_context_purges_total",
+			"Total number of purge runs.", nil, nil),
+		maxVals: prometheus.NewDesc("gorilla_context_max_values",
+			"Most values held by a single request.", nil, nil),
+		lockWait: prometheus.NewDesc("gorilla_context_lock_wait_seconds",
+			"Sampled average time spent waiting for the store lock.", nil, nil),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.requests
+	ch <- c.values
+	ch <- c.sets
+	ch <- c.gets
+	ch <- c.clears
+	ch <- c.purged
+	ch <- c.purges
+	ch <- c.maxVals
+	ch <- c.lockWait
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	s := context.Stats()
+	ch <- prometheus.MustNewConstMetric(c.requests, prometheus.GaugeValue, float64(s.Requests))
+	ch <- prometheus.MustNewConstMetric(c.values, prometheus.GaugeValue, float64(s.Values))
+	ch <- prometheus.MustNewConstMetric(c.sets, prometheus.CounterValue, float64(s.Sets))
+	ch <- prometheus.MustNewConstMetric(c.gets, prometheus.CounterValue, float64(s.Gets))
+	ch <- prometheus.MustNewConstMetric(c.clears, prometheus.CounterValue, float64(s.Clears))
+	ch <- prometheus.MustNewConstMetric(c.purged, prometheus.CounterValue, float64(s.Purged))
+	ch <- prometheus.MustNewConstMetric(c.purges, prometheus.CounterValue, float64(s.Purges))
+	ch <- prometheus.MustNewConstMetric(c.maxVals, prometheus.GaugeValue, float64(s.MaxValues))
+	ch <- prometheus.MustNewConstMetric(c.lockWait, prometheus.GaugeValue, s.LockWait.Seconds())
+}
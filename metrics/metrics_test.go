@@ -0,0 +1,40 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package metrics
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/context"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	context.Set(r, "key1", "1")
+	context.Set(r, "key2", "2")
+	defer context.Clear(r)
+
+	c := NewCollector()
+	if n := testutil.CollectAndCount(c); n != 9 {
+		t.Errorf("Expected 9 metrics, got %d.", n)
+	}
+
+	expected := `
+# HELP gorilla_context_requests Number of requests with stored values.
+# TYPE gorilla_context_requests gauge
+gorilla_context_requests 1
+# HELP gorilla_context_values Number of values stored across all requests.
+# TYPE gorilla_context_values gauge
+gorilla_context_values 2
+`
+	err := testutil.CollectAndCompare(c, strings.NewReader(expected),
+		"gorilla_context_requests", "gorilla_context_values")
+	if err != nil {
+		t.Error(err)
+	}
+}
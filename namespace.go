@@ -0,0 +1,80 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+)
+
+// namespaceKey is the key under which a Namespace stores its values.
+type namespaceKey struct {
+	name string
+	key  interface{}
+}
+
+// Namespace gives access to the values of a request stored under a name,
+// isolated from values stored with the same keys outside the namespace.
+//
+// It's meant for middleware, so that their keys can't collide with the
+// application keys.
+type Namespace struct {
+	s    Store
+	r    *http.Request
+	name string
+}
+
+// Namespace returns the namespace with a given name for a given request.
+func (reg *Registry) Namespace(r *http.Request, name string) *Namespace {
+	return &Namespace{s: reg, r: r, name: name}
+}
+
+// NewNamespace returns the namespace with a given name for a given request
+// in the default store. It's named differently from (*Registry).Namespace
+// as it would collide with the Namespace type.
+func NewNamespace(r *http.Request, name string) *Namespace {
+	return &Namespace{s: DefaultStore(), r: r, name: name}
+}
+
+// Set stores a value for a given key.
+func (ns *Namespace) Set(key, val interface{}) {
+	ns.s.Set(ns.r, namespaceKey{ns.name, key}, val)
+}
+
+// Get returns a value stored for a given key.
+func (ns *Namespace) Get(key interface{}) interface{} {
+	return ns.s.Get(ns.r, namespaceKey{ns.name, key})
+}
+
+// GetOk returns stored value and presence state like multi-value return of map access.
+func (ns *Namespace) GetOk(key interface{}) (interface{}, bool) {
+	return ns.s.GetOk(ns.r, namespaceKey{ns.name, key})
+}
+
+// GetAll returns all values stored in the namespace, keyed as they were
+// stored.
+func (ns *Namespace) GetAll() map[interface{}]interface{} {
+	result := make(map[interface{}]interface{})
+	for k, v := range rawValues(ns.s, ns.r) {
+		if k, ok := k.(namespaceKey); ok && k.name == ns.name {
+			result[k.key] = force(v)
+		}
+	}
+	return result
+}
+
+// Delete removes a value stored for a given key.
+func (ns *Namespace) Delete(key interface{}) {
+	ns.s.Delete(ns.r, namespaceKey{ns.name, key})
+}
+
+// Clear removes all values stored in the namespace. Values of the request
+// stored outside the namespace are kept.
+func (ns *Namespace) Clear() {
+	for k := range rawValues(ns.s, ns.r) {
+		if k, ok := k.(namespaceKey); ok && k.name == ns.name {
+			ns.s.Delete(ns.r, k)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestNamespace(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+
+	auth := NewNamespace(r, "auth")
+	csrf := NewNamespace(r, "csrf")
+
+	Set(r, key1, "app")
+	auth.Set(key1, "auth")
+	csrf.Set(key1, "csrf")
+	csrf.Set(key2, "token")
+
+	// Keys don't collide.
+	assertEqual(Get(r, key1), "app")
+	assertEqual(auth.Get(key1), "auth")
+	assertEqual(csrf.Get(key1), "csrf")
+	_, ok := auth.GetOk(key2)
+	assertEqual(ok, false)
+
+	values := csrf.GetAll()
+	assertEqual(len(values), 2)
+	assertEqual(values[key2], "token")
+
+	csrf.Delete(key2)
+	assertEqual(csrf.Get(key2), nil)
+
+	// Clear() only removes the namespace values.
+	auth.Clear()
+	assertEqual(auth.Get(key1), nil)
+	assertEqual(csrf.Get(key1), "csrf")
+	assertEqual(Get(r, key1), "app")
+}
@@ -0,0 +1,56 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"sort"
+)
+
+// WithInsertionOrder makes Range visit the values of a request in the
+// order their keys were stored, instead of the random order of maps, for
+// reproducible debug output and golden tests. Storing a value for a key
+// that already has one keeps its position; a key deleted and stored again
+// moves last.
+//
+// GetAll and the other functions returning maps are not affected.
+func WithInsertionOrder() Option {
+	return func(reg *Registry) {
+		reg.order = make(map[*http.Request]map[interface{}]uint64)
+	}
+}
+
+// insert records the insertion of a given key in a given request, if
+// insertion order is enabled and the key has no value yet. It must be
+// called with the lock held, before storing the value.
+func (reg *Registry) insert(r *http.Request, key interface{}) {
+	if reg.order == nil {
+		return
+	}
+	if _, ok := reg.data[r][key]; ok && !reg.expired(r, key) {
+		return
+	}
+	if reg.order[r] == nil {
+		reg.order[r] = make(map[interface{}]uint64)
+	}
+	reg.orderSeq++
+	reg.order[r][key] = reg.orderSeq
+}
+
+// keysOf returns the keys of the values stored for a given request,
+// including the expired ones, in insertion order if enabled. It must be
+// called with the lock held.
+func (reg *Registry) keysOf(r *http.Request) []interface{} {
+	keys := make([]interface{}, 0, len(reg.data[r]))
+	for k := range reg.data[r] {
+		keys = append(keys, k)
+	}
+	if order := reg.order[r]; order != nil {
+		sort.Slice(keys, func(i, j int) bool {
+			return order[keys[i]] < order[keys[j]]
+		})
+	}
+	return keys
+}
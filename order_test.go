@@ -0,0 +1,54 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestInsertionOrder(t *testing.T) {
+	reg := New(WithInsertionOrder())
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer reg.Clear(r)
+
+	keys := func(r *http.Request) []interface{} {
+		var keys []interface{}
+		reg.Range(r, func(key, val interface{}) bool {
+			keys = append(keys, key)
+			return true
+		})
+		return keys
+	}
+	assertKeys := func(r *http.Request, exp ...interface{}) {
+		t.Helper()
+		if k := keys(r); !reflect.DeepEqual(k, exp) {
+			t.Errorf("Expected %v, got %v.", exp, k)
+		}
+	}
+
+	for _, k := range []string{"e", "d", "c", "b", "a"} {
+		reg.Set(r, k, k)
+	}
+	assertKeys(r, "e", "d", "c", "b", "a")
+
+	// Storing a value again keeps its position, storing it after a
+	// deletion moves it last.
+	reg.Set(r, "d", "D")
+	reg.Delete(r, "c")
+	reg.Set(r, "c", "C")
+	assertKeys(r, "e", "d", "b", "a", "c")
+
+	// Linked values keep their order.
+	clone, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	reg.Set(clone, "z", 1)
+	reg.Set(clone, "y", 2)
+	reg.Link(r, clone)
+	assertKeys(clone, "e", "d", "b", "a", "c", "z", "y")
+
+	reg.Clear(r)
+	assertKeys(r)
+}
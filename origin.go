@@ -0,0 +1,71 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"runtime"
+	"strconv"
+)
+
+// WithOrigins enables origins for the registry, like RecordOrigins.
+func WithOrigins() Option {
+	return func(reg *Registry) {
+		reg.origins = make(map[*http.Request]map[interface{}]string)
+	}
+}
+
+// RecordOrigins enables or disables origins: when enabled, the file and
+// line of the code storing each value is recorded, and returned by Origin.
+// It's meant for debugging, to find out which middleware stored an
+// unexpected value. Unlike RecordHistory, only the latest caller of each
+// key is kept.
+//
+// Disabling origins forgets the callers recorded so far.
+func (reg *Registry) RecordOrigins(enabled bool) {
+	reg.lock()
+	switch {
+	case !enabled:
+		reg.origins = nil
+	case reg.origins == nil:
+		reg.origins = make(map[*http.Request]map[interface{}]string)
+	}
+	reg.unlock()
+}
+
+// RecordOrigins enables or disables origins for the default store. See
+// (*Registry).RecordOrigins for details.
+func RecordOrigins(enabled bool) {
+	defaultRegistry("RecordOrigins").RecordOrigins(enabled)
+}
+
+// Origin returns the file and line, as "file:line", of the code that
+// stored the value of a given key in a given request. It returns "" if the
+// value was stored while origins were disabled, or isn't stored.
+func (reg *Registry) Origin(r *http.Request, key interface{}) string {
+	reg.rlock()
+	defer reg.mutex.RUnlock()
+	return reg.origins[reg.resolve(r)][key]
+}
+
+// Origin returns the file and line, as "file:line", of the code that
+// stored the value of a given key in a given request. See
+// (*Registry).RecordOrigins.
+func Origin(r *http.Request, key interface{}) string {
+	return defaultRegistry("Origin").Origin(r, key)
+}
+
+// setOrigin records the caller of a Set, or forgets the origin of a
+// deleted key. It must be called with the lock held.
+func (reg *Registry) setOrigin(r *http.Request, op string, key interface{}, frame runtime.Frame) {
+	if op != "Set" {
+		delete(reg.origins[r], key)
+		return
+	}
+	if reg.origins[r] == nil {
+		reg.origins[r] = make(map[interface{}]string)
+	}
+	reg.origins[r][key] = frame.File + ":" + strconv.Itoa(frame.Line)
+}
@@ -0,0 +1,40 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestOrigin(t *testing.T) {
+	reg := New(WithOrigins())
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer reg.Clear(r)
+
+	reg.Set(r, key1, "1")
+	_, _, line, _ := runtime.Caller(0)
+	want := "origin_test.go:" + strconv.Itoa(line-1)
+	if o := reg.Origin(r, key1); !strings.HasSuffix(o, want) {
+		t.Errorf("Expected origin ending with %s, got %q.", want, o)
+	}
+	if o := reg.Origin(r, key2); o != "" {
+		t.Errorf("Unexpected origin %q for a missing key.", o)
+	}
+
+	reg.Delete(r, key1)
+	if o := reg.Origin(r, key1); o != "" {
+		t.Errorf("Origin %q kept for a deleted key.", o)
+	}
+
+	reg.RecordOrigins(false)
+	reg.Set(r, key1, "1")
+	if o := reg.Origin(r, key1); o != "" {
+		t.Errorf("Origin %q recorded while disabled.", o)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package otelattr copies request values to OpenTelemetry spans.
+//
+// Values are copied as attributes of the span in the request context when
+// the request is cleared, so that everything stored during the request
+// shows up in traces:
+//
+//	h = otelhttp.NewHandler(context.ClearHandler(otelattr.Handler(h, map[interface{}]string{
+//		userKey:   "app.user",
+//		tenantKey: "app.tenant",
+//	})), "server")
+package otelattr
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/context"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Export registers an OnClear function that copies the values stored for
+// the keys of mapping to the span in the request context, as the attributes
+// they map to. Keys with no value are skipped, and the values of sensitive
+// keys are redacted, see context.MarkSensitive.
+func Export(r *http.Request, mapping map[interface{}]string) {
+	context.OnClear(r, func() {
+		span := trace.SpanFromContext(r.Context())
+		if !span.IsRecording() {
+			return
+		}
+		attrs := make([]attribute.KeyValue, 0, len(mapping))
+		for key, name := range mapping {
+			if value, ok := context.GetOk(r, key); ok {
+				if context.IsSensitive(key) {
+					value = context.Redacted
+				}
+				attrs = append(attrs, Attribute(name, value))
+			}
+		}
+		span.SetAttributes(attrs...)
+	})
+}
+
+// Handler wraps an http.Handler and calls Export for every request. It
+// must be wrapped by ClearHandler, itself inside the handler starting the
+// span.
+func Handler(h http.Handler, mapping map[interface{}]string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Export(r, mapping)
+		h.ServeHTTP(w, r)
+	})
+}
+
+// Attribute returns an attribute for a request value. Strings, booleans,
+// integers and floats keep their type; other values are formatted with
+// fmt.Sprint.
+func Attribute(name string, value interface{}) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(name, v)
+	case bool:
+		return attribute.Bool(name, v)
+	case int:
+		return attribute.Int(name, v)
+	case int64:
+		return attribute.Int64(name, v)
+	case float64:
+		return attribute.Float64(name, v)
+	case []string:
+		return attribute.StringSlice(name, v)
+	}
+	return attribute.String(name, fmt.Sprint(value))
+}
@@ -0,0 +1,54 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package otelattr
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/context"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestHandler(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	h := context.ClearHandler(Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		context.Set(r, "user", "alice")
+		context.Set(r, "retries", 2)
+		context.Set(r, "secret", "hidden")
+	}), map[interface{}]string{
+		"user":    "app.user",
+		"retries": "app.retries",
+		"missing": "app.missing",
+	}))
+
+	r := httptest.NewRequest("GET", "http://localhost:8080/", nil)
+	ctx, span := provider.Tracer("test").Start(r.Context(), "request")
+	h.ServeHTTP(httptest.NewRecorder(), r.WithContext(ctx))
+	span.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d.", len(spans))
+	}
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range spans[0].Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	if len(attrs) != 2 {
+		t.Errorf("Expected 2 attributes, got %v.", attrs)
+	}
+	if v := attrs["app.user"]; v.AsString() != "alice" {
+		t.Errorf("Expected app.user alice, got %v.", v.Emit())
+	}
+	if v := attrs["app.retries"]; v.AsInt64() != 2 {
+		t.Errorf("Expected app.retries 2, got %v.", v.Emit())
+	}
+}
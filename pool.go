@@ -0,0 +1,31 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+// maxPooledValues is the size past which the value maps of cleared requests
+// are left to the garbage collector: maps don't shrink, so reusing a large
+// one would waste memory.
+const maxPooledValues = 32
+
+// newValues returns an empty map for the values of a request, reusing the
+// map of a cleared request if possible.
+func (reg *Registry) newValues() map[interface{}]interface{} {
+	if values, ok := reg.pool.Get().(map[interface{}]interface{}); ok {
+		return values
+	}
+	return make(map[interface{}]interface{})
+}
+
+// putValues makes the map of a cleared request available to newValues.
+// It must only be called when nothing else references the map.
+func (reg *Registry) putValues(values map[interface{}]interface{}) {
+	if len(values) > maxPooledValues {
+		return
+	}
+	for k := range values {
+		delete(values, k)
+	}
+	reg.pool.Put(values)
+}
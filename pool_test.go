@@ -0,0 +1,61 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestPoolHandle(t *testing.T) {
+	reg := New()
+	r1, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	r2, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+
+	// A stale Context can't see the values of another request reusing the
+	// map of its own.
+	c := reg.Handle(r1)
+	c.Set(key1, "1")
+	reg.Clear(r1)
+	for i := 0; i < 10; i++ {
+		reg.Set(r2, key2, "2")
+		reg.Clear(r2)
+	}
+	reg.Set(r2, key2, "2")
+	if v := c.Get(key2); v != nil {
+		t.Errorf("Stale Context sees %v.", v)
+	}
+}
+
+func TestPoolValues(t *testing.T) {
+	reg := New()
+	values := map[interface{}]interface{}{key1: "1"}
+	reg.putValues(values)
+	if len(values) != 0 {
+		t.Errorf("Expected an empty map, got %v.", values)
+	}
+}
+
+func benchmarkSetClear(b *testing.B, keys int) {
+	reg := New()
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for k := 0; k < keys; k++ {
+			reg.Set(r, keyType(k), k)
+		}
+		reg.Clear(r)
+	}
+}
+
+func BenchmarkSetClear1(b *testing.B) {
+	benchmarkSetClear(b, 1)
+}
+func BenchmarkSetClear4(b *testing.B) {
+	benchmarkSetClear(b, 4)
+}
+func BenchmarkSetClear16(b *testing.B) {
+	benchmarkSetClear(b, 16)
+}
@@ -0,0 +1,49 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	gocontext "context"
+	"fmt"
+	"net/http"
+	"runtime/pprof"
+	"sort"
+)
+
+// ProfileLabelsHandler wraps an http.Handler and runs it with pprof labels
+// set from request values, so that CPU profiles can be broken down by
+// route, tenant, and the like. The values stored for the keys of mapping
+// are formatted with fmt.Sprint and set as the labels they map to; keys
+// with no value are skipped, and the values of sensitive keys are
+// redacted, see MarkSensitive.
+//
+// The values are read when the handler is called, so it must be wrapped by
+// the handlers storing them. The wrapped handler receives a request linked
+// to the original one, see WithContext.
+func ProfileLabelsHandler(h http.Handler, mapping map[interface{}]string) http.Handler {
+	names := make([]string, 0, len(mapping))
+	keys := make(map[string]interface{}, len(mapping))
+	for k, name := range mapping {
+		names = append(names, name)
+		keys[name] = k
+	}
+	sort.Strings(names)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		labels := make([]string, 0, 2*len(names))
+		for _, name := range names {
+			k := keys[name]
+			if value, ok := GetOk(r, k); ok {
+				labels = append(labels, name, fmt.Sprint(redactValue(k, value)))
+			}
+		}
+		if len(labels) == 0 {
+			h.ServeHTTP(w, r)
+			return
+		}
+		pprof.Do(r.Context(), pprof.Labels(labels...), func(ctx gocontext.Context) {
+			h.ServeHTTP(w, WithContext(r, ctx))
+		})
+	})
+}
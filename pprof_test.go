@@ -0,0 +1,49 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime/pprof"
+	"testing"
+)
+
+func TestProfileLabelsHandler(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		t.Helper()
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	MarkSensitive(passwordKey)
+	var labels map[string]string
+	inner := ProfileLabelsHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		labels = make(map[string]string)
+		pprof.ForLabels(r.Context(), func(k, v string) bool {
+			labels[k] = v
+			return true
+		})
+		// The request is linked to the original one.
+		Set(r, key2, "2")
+	}), map[interface{}]string{
+		key1:        "tenant",
+		passwordKey: "password",
+		"missing":   "missing",
+	})
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Set(r, key1, "acme")
+		Set(r, passwordKey, "secret")
+		inner.ServeHTTP(w, r)
+		assertEqual(Get(r, key2), "2")
+		Clear(r)
+	})
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assertEqual(len(labels), 2)
+	assertEqual(labels["tenant"], "acme")
+	assertEqual(labels["password"], Redacted)
+}
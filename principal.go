@@ -0,0 +1,32 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+)
+
+// principalKey is the key the principal of a request is stored under.
+type principalKey struct{}
+
+// SetPrincipal stores the authenticated principal of a given request, such
+// as a user or the claims of a token. Authentication middleware calls it
+// so that handlers can retrieve the principal with Principal, whatever the
+// middleware used.
+func SetPrincipal(r *http.Request, p interface{}) {
+	Set(r, principalKey{}, p)
+}
+
+// Principal returns the principal stored for a given request by
+// SetPrincipal, and whether one of type T was found.
+func Principal[T any](r *http.Request) (T, bool) {
+	if v, ok := GetOk(r, principalKey{}); ok {
+		if p, ok := v.(T); ok {
+			return p, true
+		}
+	}
+	var zero T
+	return zero, false
+}
@@ -0,0 +1,37 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestPrincipal(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	type user struct{ name string }
+
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+
+	u, ok := Principal[*user](r)
+	assertEqual(u, (*user)(nil))
+	assertEqual(ok, false)
+
+	SetPrincipal(r, &user{"alice"})
+	u, ok = Principal[*user](r)
+	assertEqual(u.name, "alice")
+	assertEqual(ok, true)
+
+	// Wrong type.
+	s, ok := Principal[string](r)
+	assertEqual(s, "")
+	assertEqual(ok, false)
+}
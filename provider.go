@@ -0,0 +1,64 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// ErrNoProvider is returned by Resolve for keys with no provider.
+var ErrNoProvider = errors.New("context: no provider registered for key")
+
+// Provider constructs the value of a key for a given request.
+type Provider func(r *http.Request) (interface{}, error)
+
+// providers holds the providers registered with RegisterProvider.
+var providers sync.Map
+
+// providerKey is the key Resolve memoizes the values of a key under.
+type providerKey struct {
+	key interface{}
+}
+
+// RegisterProvider registers the function constructing the value of a key
+// for Resolve, replacing the previous one, if any. Providers are shared by
+// all requests and registries, and are usually registered at init time:
+//
+//	context.RegisterProvider(repoKey, func(r *http.Request) (interface{}, error) {
+//		return NewRepo(db, context.TraceID(r)), nil
+//	})
+func RegisterProvider(key interface{}, p Provider) {
+	providers.Store(key, p)
+}
+
+// Resolve returns the value of a given key for a given request, calling
+// its provider the first time only: later calls return the same value,
+// until the request is cleared. Errors aren't cached, so the provider is
+// called again on the next call. If no provider is registered for the key,
+// the error is ErrNoProvider.
+//
+// Concurrent calls for the same key wait for the first one to complete.
+// Providers may resolve other keys, but not the key they provide.
+func (reg *Registry) Resolve(r *http.Request, key interface{}) (interface{}, error) {
+	p, ok := providers.Load(key)
+	if !ok {
+		return nil, ErrNoProvider
+	}
+	v, err := reg.Memoize(r, providerKey{key}, func() (interface{}, error) {
+		return p.(Provider)(r)
+	})
+	if err != nil {
+		reg.Forget(r, providerKey{key})
+	}
+	return v, err
+}
+
+// Resolve returns the value of a given key for a given request, calling
+// its provider the first time only. See (*Registry).Resolve for details.
+func Resolve(r *http.Request, key interface{}) (interface{}, error) {
+	return defaultRegistry("Resolve").Resolve(r, key)
+}
@@ -0,0 +1,72 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+type providedKey int
+
+const (
+	counterKey providedKey = iota
+	failingKey
+	dependentKey
+)
+
+func TestResolve(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		t.Helper()
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	calls := 0
+	RegisterProvider(counterKey, func(r *http.Request) (interface{}, error) {
+		calls++
+		return calls, nil
+	})
+	failures := 0
+	RegisterProvider(failingKey, func(r *http.Request) (interface{}, error) {
+		failures++
+		return nil, errors.New("unavailable")
+	})
+	RegisterProvider(dependentKey, func(r *http.Request) (interface{}, error) {
+		v, err := Resolve(r, counterKey)
+		return v.(int) * 10, err
+	})
+
+	r1, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	r2, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r1)
+	defer Clear(r2)
+
+	v, err := Resolve(r1, counterKey)
+	assertEqual(v, 1)
+	assertEqual(err, nil)
+	v, _ = Resolve(r1, counterKey)
+	assertEqual(v, 1)
+	v, _ = Resolve(r1, dependentKey)
+	assertEqual(v, 10)
+	v, _ = Resolve(r2, counterKey)
+	assertEqual(v, 2)
+
+	// Errors aren't cached.
+	Resolve(r1, failingKey)
+	_, err = Resolve(r1, failingKey)
+	assertEqual(err.Error(), "unavailable")
+	assertEqual(failures, 2)
+
+	_, err = Resolve(r1, "unknown")
+	assertEqual(err, ErrNoProvider)
+
+	// Cleared requests resolve new values.
+	Clear(r1)
+	v, _ = Resolve(r1, counterKey)
+	assertEqual(v, 3)
+}
@@ -0,0 +1,70 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	gocontext "context"
+	"net/http"
+	"sync"
+)
+
+type proxyContextKey int
+
+// proxyKey is the context key of the proxyState of a request served by a
+// ProxyHandler.
+const proxyKey proxyContextKey = 0
+
+// proxyState tracks the outgoing requests of a proxied request.
+type proxyState struct {
+	in  *http.Request
+	mu  sync.Mutex
+	out []*http.Request
+}
+
+// ProxyHandler wraps an http.Handler, typically an httputil.ReverseProxy
+// whose Director was returned by ProxyDirector, so that the director can
+// find the inbound request of the outgoing requests it's given. Values set
+// for the outgoing requests are cleared when the wrapped handler returns.
+func ProxyHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state := &proxyState{in: r}
+		defer func() {
+			state.mu.Lock()
+			defer state.mu.Unlock()
+			for _, out := range state.out {
+				Clear(out)
+			}
+		}()
+		h.ServeHTTP(w, WithContext(r, gocontext.WithValue(r.Context(), proxyKey, state)))
+	})
+}
+
+// ProxyDirector returns a function to be used as the Director of an
+// httputil.ReverseProxy. It calls next, if not nil, and then copies the
+// values stored for the given keys, or all values if none is given, from
+// the inbound request to the outgoing one.
+//
+// The proxy must be wrapped by ProxyHandler; otherwise values are not
+// copied.
+//
+// The proxy derives a new request from the outgoing one before sending it,
+// so the Transport doesn't receive the request the values were copied to.
+// It can be found with FromStdContext(r.Context()).
+func ProxyDirector(next func(*http.Request), keys ...interface{}) func(*http.Request) {
+	return func(out *http.Request) {
+		if next != nil {
+			next(out)
+		}
+		state, ok := out.Context().Value(proxyKey).(*proxyState)
+		if !ok {
+			return
+		}
+		Copy(out, state.in, keys...)
+		*out = *out.WithContext(gocontext.WithValue(out.Context(), requestKey, out))
+		state.mu.Lock()
+		state.out = append(state.out, out)
+		state.mu.Unlock()
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"testing"
+)
+
+func TestProxyDirector(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer backend.Close()
+	target, _ := url.Parse(backend.URL)
+
+	var out *http.Request
+	var outValues map[interface{}]interface{}
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.Director = ProxyDirector(proxy.Director, key1)
+	// Inspect the outgoing request once the director is done.
+	proxy.Transport = roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		out, _ = FromStdContext(r.Context())
+		outValues = GetAll(out)
+		return http.DefaultTransport.RoundTrip(r)
+	})
+
+	r := httptest.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+	Set(r, key1, "1")
+	Set(r, key2, "2")
+	ProxyHandler(proxy).ServeHTTP(httptest.NewRecorder(), r)
+
+	if out == nil {
+		t.Fatal("Request wasn't proxied")
+	}
+	if len(outValues) != 1 || outValues[key1] != "1" {
+		t.Errorf("Unexpected outgoing values %v.", outValues)
+	}
+	if _, ok := GetAllOk(out); ok {
+		t.Error("Outgoing request wasn't cleared")
+	}
+	if Get(r, key2) != "2" {
+		t.Error("Inbound request values were lost")
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
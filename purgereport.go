@@ -0,0 +1,55 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"time"
+)
+
+// PurgedRequest describes a request removed by purging.
+type PurgedRequest struct {
+	// Request is the removed request.
+	Request *http.Request
+	// Age is the time since a value was first stored for the request.
+	Age time.Duration
+	// Keys are the keys that were stored for the request.
+	Keys []interface{}
+}
+
+// WithPurgeReport makes the registry call fn for each request removed by
+// Purge, PurgeStep or the janitor, after its OnClear functions. Requests
+// that are purged were usually not cleared because a handler isn't wrapped
+// by ClearHandler, which the reports help find.
+func WithPurgeReport(fn func(PurgedRequest)) Option {
+	return func(reg *Registry) {
+		reg.purgeReport = fn
+	}
+}
+
+// purgeRequest removes a request for purging, and appends its OnClear
+// functions and its report to fns. It must be called with the lock held.
+func (reg *Registry) purgeRequest(fns []func(), r *http.Request) []func() {
+	fns = reg.takeHooks(fns, r)
+	if fn := reg.purgeReport; fn != nil {
+		p := reg.purgedRequest(r)
+		fns = append(fns, func() { fn(p) })
+	}
+	reg.clear(r)
+	return fns
+}
+
+// purgedRequest describes a given request, which is about to be purged. It
+// must be called with the lock held.
+func (reg *Registry) purgedRequest(r *http.Request) PurgedRequest {
+	p := PurgedRequest{
+		Request: r,
+		Age:     reg.now(r).Sub(time.Unix(reg.datat[r], 0)),
+	}
+	for k := range reg.data[r] {
+		p.Keys = append(p.Keys, k)
+	}
+	return p
+}
@@ -0,0 +1,38 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestPurgeReport(t *testing.T) {
+	var reports []PurgedRequest
+	reg := New(WithPurgeReport(func(p PurgedRequest) {
+		reports = append(reports, p)
+	}))
+	r, _ := http.NewRequest("GET", "http://localhost:8080/leaky", nil)
+	reg.Set(r, key1, "1")
+	reg.mutex.Lock()
+	reg.datat[r] -= 120
+	reg.mutex.Unlock()
+
+	reg.Purge(60)
+	if len(reports) != 1 {
+		t.Fatalf("Expected 1 report, got %d.", len(reports))
+	}
+	p := reports[0]
+	if p.Request != r || p.Age < 2*time.Minute || len(p.Keys) != 1 || p.Keys[0] != key1 {
+		t.Errorf("Unexpected report %+v.", p)
+	}
+
+	reg.Set(r, key1, "1")
+	reg.PurgeStep(0, 10)
+	if len(reports) != 2 {
+		t.Errorf("Expected 2 reports, got %d.", len(reports))
+	}
+}
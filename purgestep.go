@@ -0,0 +1,73 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"math"
+	"net/http"
+	"sync/atomic"
+)
+
+// PurgeStep is an incremental Purge: it examines at most n requests, and
+// removes those stored for longer than maxAge, in seconds, along with
+// their expired values. It returns the amount of requests removed.
+//
+// Successive calls walk through the requests in registration order,
+// starting a new pass once every request was examined, so that the
+// registry is locked for a time bounded by n on each call. Requests
+// registered during a pass are examined by it. In strict mode, the cleared
+// requests older than maxAge are forgotten when a pass starts.
+//
+// If maxAge <= 0, all examined requests are removed. If n <= 0, no request
+// is examined.
+func (reg *Registry) PurgeStep(maxAge, n int) int {
+	if n <= 0 {
+		return 0
+	}
+	min := int64(math.MaxInt64)
+	if maxAge > 0 {
+		min = now().Unix() - int64(maxAge)
+	}
+	t := now()
+	count := 0
+	var fns []func()
+	reg.lock()
+	if reg.cursor == nil {
+		reg.cursor = reg.arrivals.Front()
+		reg.purgeCleared(min)
+	}
+	for i := 0; i < n && reg.cursor != nil; i++ {
+		r := reg.cursor.Value.(*http.Request)
+		reg.cursor = reg.cursor.Next()
+		if reg.lastActive(r) < min {
+			fns = reg.purgeRequest(fns, r)
+			count++
+		} else {
+			reg.reapExpiredOf(r, reg.now(r))
+		}
+	}
+	atomic.AddUint64(&reg.counters.purged, uint64(count))
+	atomic.AddUint64(&reg.counters.purges, 1)
+	reg.lastPurge = t.UnixNano()
+	reg.unlock()
+	runHooks(fns)
+	return count
+}
+
+// PurgeStep is an incremental Purge: it examines at most n requests, and
+// removes those stored for longer than maxAge, in seconds, along with
+// their expired values. It returns the amount of requests removed.
+//
+// Successive calls walk through the requests in registration order,
+// starting a new pass once every request was examined, so that the context
+// is locked for a time bounded by n on each call. Requests registered
+// during a pass are examined by it. In strict mode, the cleared requests
+// older than maxAge are forgotten when a pass starts.
+//
+// If maxAge <= 0, all examined requests are removed. If n <= 0, no request
+// is examined.
+func PurgeStep(maxAge, n int) int {
+	return defaultRegistry("PurgeStep").PurgeStep(maxAge, n)
+}
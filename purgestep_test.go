@@ -0,0 +1,71 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestPurgeStep(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	reg := New()
+	var reqs []*http.Request
+	for i := 0; i < 10; i++ {
+		r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+		reg.Set(r, key1, i)
+		reqs = append(reqs, r)
+	}
+	// Half of the requests are old, and one has an expired value.
+	reg.mutex.Lock()
+	for _, r := range reqs[:5] {
+		reg.datat[r] -= 120
+	}
+	reg.mutex.Unlock()
+	reg.SetWithTTL(reqs[9], key2, "expired", -time.Second)
+	// Cleared requests are skipped.
+	reg.Clear(reqs[0])
+
+	removed := 0
+	for i := 0; i < 3; i++ {
+		removed += reg.PurgeStep(60, 4)
+	}
+	assertEqual(removed, 4)
+	assertEqual(reg.Stats().Requests, 5)
+	assertEqual(reg.cursor == nil, true)
+	_, ok := reg.GetOk(reqs[9], key2)
+	assertEqual(ok, false)
+	assertEqual(len(reg.expires), 0)
+
+	// A new pass starts, in registration order, and moves past the requests
+	// cleared meanwhile.
+	assertEqual(reg.PurgeStep(0, 2), 2)
+	assertEqual(reg.cursor.Value, reqs[7])
+	reg.Clear(reqs[7])
+	assertEqual(reg.cursor.Value, reqs[8])
+
+	// No request is examined for n <= 0.
+	assertEqual(reg.PurgeStep(0, 0), 0)
+	assertEqual(reg.PurgeStep(0, -1), 0)
+	assertEqual(reg.Stats().Requests, 2)
+	assertEqual(reg.PurgeStep(0, 1), 1)
+	assertEqual(reg.Get(reqs[9], key1), 9)
+
+	// Strict mode forgets old cleared requests when a pass starts.
+	reg = New(WithStrictMode(func(UseAfterClear) {}))
+	reg.Set(reqs[0], key1, "value")
+	reg.Clear(reqs[0])
+	reg.mutex.Lock()
+	reg.cleared[reqs[0]] -= 120
+	reg.mutex.Unlock()
+	reg.PurgeStep(60, 1)
+	assertEqual(len(reg.cleared), 0)
+}
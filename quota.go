@@ -0,0 +1,75 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"reflect"
+)
+
+// Quota limits the values stored for each request of a registry. Limits
+// that are 0 are not enforced.
+type Quota struct {
+	// MaxKeys is the amount of values a request may hold.
+	MaxKeys int
+	// MaxBytes is the approximate size the values of a request may take.
+	// The size of a value is its length for strings, byte slices, slices
+	// and maps, times the size of their elements, and the size of its type
+	// otherwise. What pointers point to isn't counted.
+	MaxBytes int
+	// OnExceeded is called when a value stored for key makes a request go
+	// over its quota, with the resulting amount of values and size; size
+	// is only computed if MaxBytes is set. The value is stored anyway.
+	//
+	// It runs while the registry is locked and must not use it.
+	OnExceeded func(r *http.Request, key interface{}, keys, size int)
+}
+
+// WithQuota sets limits to the values stored for each request, so that a
+// misbehaving handler storing too much is noticed.
+func WithQuota(q Quota) Option {
+	return func(reg *Registry) {
+		reg.quota = &q
+	}
+}
+
+// checkQuota calls OnExceeded if the values of a request are over quota
+// after a value was stored for key. It must be called with the lock held.
+func (reg *Registry) checkQuota(r *http.Request, key interface{}) {
+	q := reg.quota
+	if q.OnExceeded == nil {
+		return
+	}
+	keys, size := len(reg.data[r]), 0
+	if q.MaxBytes > 0 {
+		for _, v := range reg.data[r] {
+			size += approxSize(v)
+		}
+	}
+	if (q.MaxKeys > 0 && keys > q.MaxKeys) || (q.MaxBytes > 0 && size > q.MaxBytes) {
+		q.OnExceeded(r, key, keys, size)
+	}
+}
+
+// approxSize returns the approximate size of a value, as described by
+// Quota.MaxBytes.
+func approxSize(v interface{}) int {
+	switch v := v.(type) {
+	case nil:
+		return 0
+	case string:
+		return len(v)
+	case []byte:
+		return len(v)
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		return rv.Len() * int(rv.Type().Elem().Size())
+	case reflect.Map:
+		return rv.Len() * int(rv.Type().Key().Size()+rv.Type().Elem().Size())
+	}
+	return int(rv.Type().Size())
+}
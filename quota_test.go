@@ -0,0 +1,68 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestQuota(t *testing.T) {
+	type violation struct {
+		key         interface{}
+		keys, bytes int
+	}
+	var violations []violation
+	reg := New(WithQuota(Quota{
+		MaxKeys:  2,
+		MaxBytes: 100,
+		OnExceeded: func(r *http.Request, key interface{}, keys, size int) {
+			violations = append(violations, violation{key, keys, size})
+		},
+	}))
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer reg.Clear(r)
+
+	reg.Set(r, key1, "small")
+	reg.Set(r, key2, "small")
+	if len(violations) != 0 {
+		t.Errorf("Unexpected violations %v.", violations)
+	}
+
+	reg.Set(r, "third", "x")
+	reg.Set(r, key2, strings.Repeat("x", 200))
+	if len(violations) != 2 {
+		t.Fatalf("Expected 2 violations, got %v.", violations)
+	}
+	if v := violations[0]; v.key != "third" || v.keys != 3 {
+		t.Errorf("Unexpected violation %v.", v)
+	}
+	if v := violations[1]; v.key != key2 || v.bytes != 206 {
+		t.Errorf("Unexpected violation %v.", v)
+	}
+	// Values are still stored.
+	if reg.Get(r, "third") != "x" {
+		t.Error("Value over quota wasn't stored")
+	}
+}
+
+func TestApproxSize(t *testing.T) {
+	for _, test := range []struct {
+		v    interface{}
+		size int
+	}{
+		{nil, 0},
+		{"abc", 3},
+		{[]byte("abcd"), 4},
+		{[]int64{1, 2}, 16},
+		{map[int32]int32{1: 1}, 8},
+		{int64(1), 8},
+	} {
+		if got := approxSize(test.v); got != test.size {
+			t.Errorf("Expected size %d for %v, got %d.", test.size, test.v, got)
+		}
+	}
+}
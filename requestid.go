@@ -0,0 +1,68 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// RequestIDHeader is the header RequestIDHandler reads and writes request
+// IDs from.
+const RequestIDHeader = "X-Request-Id"
+
+// RequestIDKey is the key RequestIDHandler stores request IDs under.
+var RequestIDKey = NewKey[string]("request-id")
+
+// maxRequestIDLength is the length past which inbound request IDs are
+// replaced.
+const maxRequestIDLength = 128
+
+// RequestIDHandler wraps an http.Handler and stores a unique ID for every
+// request before calling it. The ID is taken from the X-Request-Id header
+// of the request, if valid, or generated otherwise. It's also set as an
+// X-Request-Id header of the response.
+func RequestIDHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		RequestIDKey.Set(r, id)
+		w.Header().Set(RequestIDHeader, id)
+		h.ServeHTTP(w, r)
+	})
+}
+
+// RequestID returns the ID stored for a given request by RequestIDHandler,
+// or "".
+func RequestID(r *http.Request) string {
+	id, _ := RequestIDKey.Get(r)
+	return id
+}
+
+// newRequestID returns a random 128-bit ID, hex encoded.
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic("context: can't generate request ID: " + err.Error())
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// validRequestID reports whether an inbound request ID can be used as is:
+// it must be non-empty, not too long and made of printable ASCII.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,62 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestIDHandler(t *testing.T) {
+	var id string
+	h := RequestIDHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id = RequestID(r)
+	}))
+	serve := func(header string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "http://localhost:8080/", nil)
+		defer Clear(r)
+		if header != "" {
+			r.Header.Set(RequestIDHeader, header)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		return rec
+	}
+
+	// Generated.
+	rec := serve("")
+	if len(id) != 32 {
+		t.Errorf("Unexpected generated ID %q.", id)
+	}
+	if rec.Header().Get(RequestIDHeader) != id {
+		t.Errorf("Response header doesn't match ID %q.", id)
+	}
+	first := id
+	serve("")
+	if id == first {
+		t.Error("Generated IDs are not unique")
+	}
+
+	// Reused.
+	serve("abc-123")
+	if id != "abc-123" {
+		t.Errorf("Expected abc-123, got %q.", id)
+	}
+
+	// Invalid IDs are replaced.
+	for _, header := range []string{strings.Repeat("a", 129), "with space"} {
+		serve(header)
+		if id == header || len(id) != 32 {
+			t.Errorf("Invalid ID %q wasn't replaced, got %q.", header, id)
+		}
+	}
+
+	r := httptest.NewRequest("GET", "http://localhost:8080/", nil)
+	if RequestID(r) != "" {
+		t.Error("Expected no request ID")
+	}
+}
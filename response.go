@@ -0,0 +1,185 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ResponseKey is the type of the keys ResponseHandler stores values under.
+type ResponseKey int
+
+// Keys under which ResponseHandler stores what it recorded of a response.
+const (
+	// StatusKey holds the status code, as an int.
+	StatusKey ResponseKey = iota
+	// BytesWrittenKey holds the size of the body, as an int64.
+	BytesWrittenKey
+	// DurationKey holds the time spent in the wrapped handler, as a
+	// time.Duration.
+	DurationKey
+)
+
+// ResponseHandler wraps an http.Handler and records the status code, the
+// body size and the duration of its responses, once it returns. It's meant
+// to be wrapped by logging middleware, which can then read them with Get:
+//
+//	h = logHandler(context.ResponseHandler(h))
+//
+// Informational statuses sent before the final one, such as 103 Early
+// Hints, aren't recorded; 101 Switching Protocols is. No status is recorded
+// for a connection hijacked before anything was written.
+//
+// The ResponseWriter passed to the wrapped handler implements http.Flusher,
+// http.Hijacker and http.Pusher when the original one does.
+func ResponseHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sw := &statusWriter{ResponseWriter: w}
+		start := time.Now()
+		defer func() {
+			status := sw.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if sw.status != 0 || !sw.hijacked {
+				setResponse(r, StatusKey, status)
+			}
+			setResponse(r, BytesWrittenKey, sw.bytes)
+			setResponse(r, DurationKey, time.Since(start))
+		}()
+		h.ServeHTTP(wrapWriter(sw), r)
+	})
+}
+
+// setResponse stores a value recorded by ResponseHandler. It's stored even
+// if the request is frozen, since it describes the response rather than
+// the values the handlers were locked out of.
+func setResponse(r *http.Request, key, val interface{}) {
+	if s, ok := DefaultStore().(interface{ registry() *Registry }); ok {
+		s.registry().setUnchecked(r, key, val)
+		return
+	}
+	Set(r, key, val)
+}
+
+// statusWriter records the status code and the body size of a response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+	// hijacked reports whether the connection was hijacked.
+	hijacked bool
+	// beforeHeader, if set, is called before the header is written.
+	beforeHeader func()
+}
+
+// setStatus records the status code of the response, the first time it's
+// called.
+func (w *statusWriter) setStatus(status int) {
+	if w.status != 0 {
+		return
+	}
+	w.status = status
+	if w.beforeHeader != nil {
+		w.beforeHeader()
+	}
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	// Informational statuses precede the final one.
+	if status < 100 || status >= 200 || status == http.StatusSwitchingProtocols {
+		w.setStatus(status)
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.setStatus(http.StatusOK)
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap returns the original ResponseWriter, for http.ResponseController.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+type flushWriter struct{ w *statusWriter }
+
+func (f flushWriter) Flush() {
+	f.w.setStatus(http.StatusOK)
+	f.w.ResponseWriter.(http.Flusher).Flush()
+}
+
+type hijackWriter struct{ w *statusWriter }
+
+func (h hijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := h.w.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil {
+		h.w.hijacked = true
+	}
+	return conn, rw, err
+}
+
+type pushWriter struct{ w *statusWriter }
+
+func (p pushWriter) Push(target string, opts *http.PushOptions) error {
+	return p.w.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+// wrapWriter returns a ResponseWriter implementing the optional interfaces
+// of the original ResponseWriter of w, and only those.
+func wrapWriter(w *statusWriter) http.ResponseWriter {
+	_, f := w.ResponseWriter.(http.Flusher)
+	_, h := w.ResponseWriter.(http.Hijacker)
+	_, p := w.ResponseWriter.(http.Pusher)
+	switch {
+	case f && h && p:
+		return struct {
+			*statusWriter
+			flushWriter
+			hijackWriter
+			pushWriter
+		}{w, flushWriter{w}, hijackWriter{w}, pushWriter{w}}
+	case f && h:
+		return struct {
+			*statusWriter
+			flushWriter
+			hijackWriter
+		}{w, flushWriter{w}, hijackWriter{w}}
+	case f && p:
+		return struct {
+			*statusWriter
+			flushWriter
+			pushWriter
+		}{w, flushWriter{w}, pushWriter{w}}
+	case h && p:
+		return struct {
+			*statusWriter
+			hijackWriter
+			pushWriter
+		}{w, hijackWriter{w}, pushWriter{w}}
+	case f:
+		return struct {
+			*statusWriter
+			flushWriter
+		}{w, flushWriter{w}}
+	case h:
+		return struct {
+			*statusWriter
+			hijackWriter
+		}{w, hijackWriter{w}}
+	case p:
+		return struct {
+			*statusWriter
+			pushWriter
+		}{w, pushWriter{w}}
+	}
+	return w
+}
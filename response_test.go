@@ -0,0 +1,140 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestResponseHandler(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	var flusher bool
+	h := ResponseHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, flusher = w.(http.Flusher)
+		_, hijacker := w.(http.Hijacker)
+		assertEqual(hijacker, false)
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Path == "/empty" {
+			return
+		}
+		if r.URL.Path == "/frozen" {
+			Freeze(r)
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		time.Sleep(time.Millisecond)
+		w.Write([]byte("hello"))
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	serve := func(path string) *http.Request {
+		r, _ := http.NewRequest("GET", "http://localhost:8080"+path, nil)
+		h.ServeHTTP(httptest.NewRecorder(), r)
+		return r
+	}
+
+	r := serve("/")
+	defer Clear(r)
+	assertEqual(Get(r, StatusKey), http.StatusOK)
+	assertEqual(Get(r, BytesWrittenKey), int64(5))
+	if d, _ := Get(r, DurationKey).(time.Duration); d < time.Millisecond {
+		t.Errorf("Unexpected duration %v.", d)
+	}
+	assertEqual(flusher, true)
+
+	r = serve("/missing")
+	defer Clear(r)
+	assertEqual(Get(r, StatusKey), http.StatusNotFound)
+
+	r = serve("/empty")
+	defer Clear(r)
+	assertEqual(Get(r, StatusKey), http.StatusOK)
+	assertEqual(Get(r, BytesWrittenKey), int64(0))
+
+	// The response is recorded even if the request was frozen.
+	r = serve("/frozen")
+	defer Clear(r)
+	assertEqual(Get(r, StatusKey), http.StatusAccepted)
+}
+
+// hijackRecorder is a ResponseRecorder implementing http.Hijacker.
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, nil
+}
+
+func TestResponseHandlerStatus(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	h := ResponseHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/hints":
+			w.WriteHeader(http.StatusEarlyHints)
+			w.WriteHeader(http.StatusCreated)
+		case "/upgrade":
+			w.WriteHeader(http.StatusSwitchingProtocols)
+		case "/hijack":
+			w.(http.Hijacker).Hijack()
+		}
+	}))
+	serve := func(path string) *http.Request {
+		r, _ := http.NewRequest("GET", "http://localhost:8080"+path, nil)
+		h.ServeHTTP(hijackRecorder{httptest.NewRecorder()}, r)
+		return r
+	}
+
+	// Informational statuses other than 101 aren't recorded.
+	r := serve("/hints")
+	defer Clear(r)
+	assertEqual(Get(r, StatusKey), http.StatusCreated)
+	r = serve("/upgrade")
+	defer Clear(r)
+	assertEqual(Get(r, StatusKey), http.StatusSwitchingProtocols)
+
+	// Nor is a status for connections hijacked before a write.
+	r = serve("/hijack")
+	defer Clear(r)
+	_, ok := GetOk(r, StatusKey)
+	assertEqual(ok, false)
+	assertEqual(Get(r, BytesWrittenKey), int64(0))
+}
+
+func TestWrapWriter(t *testing.T) {
+	// A plain ResponseWriter implements none of the optional interfaces.
+	w := wrapWriter(&statusWriter{ResponseWriter: struct{ http.ResponseWriter }{httptest.NewRecorder()}})
+	if _, ok := w.(http.Flusher); ok {
+		t.Error("Unexpected http.Flusher")
+	}
+	if _, ok := w.(http.Pusher); ok {
+		t.Error("Unexpected http.Pusher")
+	}
+
+	// Flushing records the implicit status.
+	sw := &statusWriter{ResponseWriter: httptest.NewRecorder()}
+	wrapWriter(sw).(http.Flusher).Flush()
+	if sw.status != http.StatusOK {
+		t.Errorf("Expected %d, got %d.", http.StatusOK, sw.status)
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+)
+
+// responseHeaderKey is the key the headers staged with SetResponseHeader
+// are stored under.
+type responseHeaderKey struct{}
+
+// SetResponseHeader stages a response header for a given request, replacing
+// any value staged for the same key. ResponseHeaderHandler sets the staged
+// headers just before the response header is written, so middleware can
+// add headers without depending on when the handler writes the response.
+func SetResponseHeader(r *http.Request, key, value string) {
+	Update(r, responseHeaderKey{}, func(old interface{}) interface{} {
+		// Copy the header, as it may be read concurrently.
+		h, _ := old.(http.Header)
+		h = h.Clone()
+		if h == nil {
+			h = make(http.Header)
+		}
+		h.Set(key, value)
+		return h
+	})
+}
+
+// ResponseHeaderHandler wraps an http.Handler and sets the headers staged
+// with SetResponseHeader on the response, before the header is written.
+//
+// The ResponseWriter passed to the wrapped handler implements http.Flusher,
+// http.Hijacker and http.Pusher when the original one does.
+func ResponseHeaderHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sw := &statusWriter{ResponseWriter: w}
+		sw.beforeHeader = func() {
+			staged, _ := Get(r, responseHeaderKey{}).(http.Header)
+			for k, v := range staged {
+				w.Header()[k] = v
+			}
+		}
+		h.ServeHTTP(wrapWriter(sw), r)
+		// Nothing was written: the server writes the header after we
+		// return.
+		sw.setStatus(http.StatusOK)
+	})
+}
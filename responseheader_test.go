@@ -0,0 +1,36 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResponseHeaderHandler(t *testing.T) {
+	h := ResponseHeaderHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		SetResponseHeader(r, "X-Staged", "early")
+		SetResponseHeader(r, "X-Staged", "replaced")
+		if r.URL.Path == "/write" {
+			w.Write([]byte("body"))
+			// Too late.
+			SetResponseHeader(r, "X-Late", "1")
+		}
+	}))
+
+	for _, path := range []string{"/write", "/empty"} {
+		r, _ := http.NewRequest("GET", "http://localhost:8080"+path, nil)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		Clear(r)
+		if got := rec.Result().Header.Get("X-Staged"); got != "replaced" {
+			t.Errorf("Expected replaced for %s, got %q.", path, got)
+		}
+		if got := rec.Result().Header.Get("X-Late"); got != "" {
+			t.Errorf("Unexpected late header %q for %s.", got, path)
+		}
+	}
+}
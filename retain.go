@@ -0,0 +1,87 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+)
+
+// retain tracks the holders of a request retained with Retain.
+type retain struct {
+	count int
+	// cleared is set when Clear was called while the request was held.
+	cleared bool
+}
+
+// Retain keeps the values of a given request alive until a matching call
+// to Release, even if the request is cleared meanwhile. It's meant for
+// handlers passing the request to background work:
+//
+//	context.Retain(r)
+//	go func() {
+//		defer context.Release(r)
+//		// ...
+//	}()
+//
+// Purge still removes retained requests.
+func (reg *Registry) Retain(r *http.Request) {
+	reg.lock()
+	r = reg.resolve(r)
+	if !reg.register(r) {
+		reg.unlock()
+		return
+	}
+	ret := reg.retains[r]
+	if ret == nil {
+		ret = new(retain)
+		reg.retains[r] = ret
+	}
+	ret.count++
+	reg.unlock()
+}
+
+// Release releases a request held with Retain. The last call performs
+// the Clear that was deferred, if any.
+func (reg *Registry) Release(r *http.Request) {
+	reg.lock()
+	r = reg.resolve(r)
+	ret := reg.retains[r]
+	if ret == nil {
+		reg.unlock()
+		return
+	}
+	ret.count--
+	if ret.count > 0 {
+		reg.unlock()
+		return
+	}
+	delete(reg.retains, r)
+	if !ret.cleared {
+		reg.unlock()
+		return
+	}
+	reg.clearAndUnlock(r)
+}
+
+// Retain keeps the values of a given request alive until a matching call
+// to Release, even if the request is cleared meanwhile. It's meant for
+// handlers passing the request to background work:
+//
+//	context.Retain(r)
+//	go func() {
+//		defer context.Release(r)
+//		// ...
+//	}()
+//
+// Purge still removes retained requests.
+func Retain(r *http.Request) {
+	defaultRegistry("Retain").Retain(r)
+}
+
+// Release releases a request held with Retain. The last call performs
+// the Clear that was deferred, if any.
+func Release(r *http.Request) {
+	defaultRegistry("Release").Release(r)
+}
@@ -0,0 +1,49 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRetain(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+
+	cleared := 0
+	Set(r, key1, "1")
+	OnClear(r, func() { cleared++ })
+
+	Retain(r)
+	Retain(r)
+	Clear(r)
+	assertEqual(Get(r, key1), "1")
+	assertEqual(cleared, 0)
+
+	Release(r)
+	assertEqual(Get(r, key1), "1")
+	Release(r)
+	assertEqual(Get(r, key1), nil)
+	assertEqual(cleared, 1)
+
+	// Releasing without a pending Clear keeps the values.
+	Set(r, key1, "1")
+	Retain(r)
+	Release(r)
+	assertEqual(Get(r, key1), "1")
+	Clear(r)
+	assertEqual(Get(r, key1), nil)
+
+	// Extra releases are ignored.
+	Release(r)
+	assertEqual(len(builtin.retains), 0)
+}
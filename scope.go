@@ -0,0 +1,176 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"math"
+	"sync"
+)
+
+// Scope stores values for owners of type K, the way a Registry does for
+// requests. It's meant for values tied to something other than a request,
+// such as a connection or a job:
+//
+//	var conns = context.NewScope[net.Conn]()
+//
+//	conns.Set(conn, userKey, user)
+//	defer conns.Clear(conn)
+//
+// A Scope only has the core API. A Registry is a Scope[*http.Request]
+// holding the values, which adds the request specific features, like Link
+// or leak detection.
+type Scope[K comparable] struct {
+	mutex sync.RWMutex
+	data  map[K]map[interface{}]interface{}
+	datat map[K]int64
+	hooks map[K][]func()
+}
+
+// NewScope returns a new, empty Scope.
+func NewScope[K comparable]() *Scope[K] {
+	s := new(Scope[K])
+	s.init()
+	return s
+}
+
+// init allocates the maps of s.
+func (s *Scope[K]) init() {
+	s.data = make(map[K]map[interface{}]interface{})
+	s.datat = make(map[K]int64)
+	s.hooks = make(map[K][]func())
+}
+
+// register initializes the data for a given owner, if not done yet. It
+// must be called with the lock held.
+func (s *Scope[K]) register(owner K) {
+	if s.data[owner] == nil {
+		s.add(owner, make(map[interface{}]interface{}), now().Unix())
+	}
+}
+
+// add registers a given owner with its values map and its creation time,
+// a Unix time in seconds. It must be called with the lock held.
+func (s *Scope[K]) add(owner K, values map[interface{}]interface{}, t int64) {
+	s.data[owner] = values
+	s.datat[owner] = t
+}
+
+// Set stores a value for a given key of a given owner.
+func (s *Scope[K]) Set(owner K, key, val interface{}) {
+	s.mutex.Lock()
+	s.register(owner)
+	s.data[owner][key] = val
+	s.mutex.Unlock()
+}
+
+// Get returns a value stored for a given key of a given owner.
+func (s *Scope[K]) Get(owner K, key interface{}) interface{} {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return force(s.data[owner][key])
+}
+
+// GetOk returns stored value and presence state like multi-value return of map access.
+func (s *Scope[K]) GetOk(owner K, key interface{}) (interface{}, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	value, ok := s.data[owner][key]
+	return force(value), ok
+}
+
+// GetAll returns all stored values of an owner as a map. Nil is returned
+// if nothing was stored for the owner.
+func (s *Scope[K]) GetAll(owner K) map[interface{}]interface{} {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	values, ok := s.data[owner]
+	if !ok {
+		return nil
+	}
+	result := make(map[interface{}]interface{}, len(values))
+	for k, v := range values {
+		result[k] = force(v)
+	}
+	return result
+}
+
+// Delete removes a value stored for a given key of a given owner.
+func (s *Scope[K]) Delete(owner K, key interface{}) {
+	s.mutex.Lock()
+	delete(s.data[owner], key)
+	s.mutex.Unlock()
+}
+
+// OnClear registers a function to be called when the values of a given
+// owner are cleared, either by Clear or by Purge. Functions are called in
+// the reverse order they were registered.
+func (s *Scope[K]) OnClear(owner K, fn func()) {
+	s.mutex.Lock()
+	s.register(owner)
+	s.hooks[owner] = append(s.hooks[owner], fn)
+	s.mutex.Unlock()
+}
+
+// Clear removes all values stored for a given owner. OnClear functions are
+// called before the values are removed.
+func (s *Scope[K]) Clear(owner K) {
+	s.mutex.Lock()
+	fns := s.takeHooks(nil, owner)
+	s.mutex.Unlock()
+	runHooks(fns)
+	s.mutex.Lock()
+	s.clear(owner)
+	s.mutex.Unlock()
+}
+
+// clear is Clear without the lock and the hooks.
+func (s *Scope[K]) clear(owner K) {
+	delete(s.data, owner)
+	delete(s.datat, owner)
+	delete(s.hooks, owner)
+}
+
+// takeHooks appends the OnClear functions of a given owner to fns, most
+// recent first, and forgets them. It must be called with the lock held.
+func (s *Scope[K]) takeHooks(fns []func(), owner K) []func() {
+	h := s.hooks[owner]
+	for i := len(h) - 1; i >= 0; i-- {
+		fns = append(fns, h[i])
+	}
+	delete(s.hooks, owner)
+	return fns
+}
+
+// Len returns the number of owners with stored values.
+func (s *Scope[K]) Len() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return len(s.data)
+}
+
+// Purge removes owner data stored for longer than maxAge, in seconds.
+// It returns the amount of owners removed.
+//
+// If maxAge <= 0, all owner data is removed. OnClear functions of the
+// removed owners are called after their values are gone.
+func (s *Scope[K]) Purge(maxAge int) int {
+	min := int64(math.MaxInt64)
+	if maxAge > 0 {
+		min = now().Unix() - int64(maxAge)
+	}
+	s.mutex.Lock()
+	count := 0
+	var fns []func()
+	for owner := range s.data {
+		if s.datat[owner] < min {
+			fns = s.takeHooks(fns, owner)
+			s.clear(owner)
+			count++
+		}
+	}
+	s.mutex.Unlock()
+	runHooks(fns)
+	return count
+}
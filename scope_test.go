@@ -0,0 +1,49 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"testing"
+)
+
+func TestScope(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	s := NewScope[string]()
+
+	assertEqual(s.Get("job1", key1), nil)
+	assertEqual(s.GetAll("job1") == nil, true)
+
+	s.Set("job1", key1, "1")
+	s.Set("job2", key1, "2")
+	assertEqual(s.Get("job1", key1), "1")
+	assertEqual(s.Get("job2", key1), "2")
+	val, ok := s.GetOk("job1", key1)
+	assertEqual(val, "1")
+	assertEqual(ok, true)
+	assertEqual(len(s.GetAll("job1")), 1)
+	assertEqual(s.Len(), 2)
+
+	s.Delete("job1", key1)
+	_, ok = s.GetOk("job1", key1)
+	assertEqual(ok, false)
+
+	var order []int
+	s.OnClear("job1", func() { order = append(order, 1) })
+	s.OnClear("job1", func() { order = append(order, 2) })
+	s.Clear("job1")
+	assertEqual(len(order), 2)
+	assertEqual(order[0], 2)
+	assertEqual(s.GetAll("job1") == nil, true)
+	assertEqual(s.Len(), 1)
+
+	assertEqual(s.Purge(10), 0)
+	assertEqual(s.Purge(0), 1)
+	assertEqual(s.Len(), 0)
+}
@@ -0,0 +1,111 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"time"
+)
+
+// shadowed is a value replaced or deleted within a scope pushed with
+// PushScope, restored by PopScope.
+type shadowed struct {
+	value   interface{}
+	existed bool
+	// seq is its position in insertion order, if enabled.
+	seq uint64
+}
+
+// PushScope starts a nested scope for a given request: the values stored
+// or deleted until the matching PopScope are reverted by it, so that
+// values set by outer code reappear. It lets middleware run inner handlers
+// without them permanently changing the request values:
+//
+//	context.PushScope(r)
+//	defer context.PopScope(r)
+//	context.Set(r, localeKey, "fr") // shadows the outer locale, if any
+//
+// Scopes don't restore the expiration of values stored with SetWithTTL.
+func (reg *Registry) PushScope(r *http.Request) {
+	reg.lock()
+	r = reg.resolve(r)
+	if reg.register(r) {
+		reg.scopes[r] = append(reg.scopes[r], make(map[interface{}]shadowed))
+	}
+	reg.unlock()
+}
+
+// PushScope starts a nested scope for a given request, reverted by
+// PopScope. See (*Registry).PushScope for details.
+func PushScope(r *http.Request) {
+	defaultRegistry("PushScope").PushScope(r)
+}
+
+// PopScope ends the innermost scope of a given request started with
+// PushScope, restoring the values stored or deleted within it. It has no
+// effect if no scope was pushed.
+//
+// The restored values keep their position in insertion order, and are
+// recorded as "PopScope" in the history. Nothing is changed if any of the
+// writes is refused, see HandleRefusedWrites.
+func (reg *Registry) PopScope(r *http.Request) {
+	reg.lock()
+	r = reg.resolve(r)
+	frames := reg.scopes[r]
+	if len(frames) == 0 {
+		reg.unlock()
+		return
+	}
+	frame := frames[len(frames)-1]
+	for key := range frame {
+		if err := reg.checkWrite(r, key); err != nil {
+			reg.unlock()
+			reg.refuse(r, err)
+			return
+		}
+	}
+	// Restoring the values doesn't change them for the outer scopes, which
+	// are detached meanwhile.
+	delete(reg.scopes, r)
+	for key, s := range frame {
+		if s.existed {
+			reg.setUntil(r, key, s.value, time.Time{}, "PopScope")
+			if s.seq != 0 {
+				reg.order[r][key] = s.seq
+			}
+		} else {
+			reg.del(r, key, "PopScope")
+		}
+	}
+	if len(frames) > 1 {
+		reg.scopes[r] = frames[:len(frames)-1]
+	}
+	reg.publish(r)
+	reg.unlock()
+}
+
+// PopScope ends the innermost scope of a given request started with
+// PushScope, restoring the values stored or deleted within it.
+func PopScope(r *http.Request) {
+	defaultRegistry("PopScope").PopScope(r)
+}
+
+// shadow saves the value of a given key before it's changed within a
+// scope, if it wasn't saved yet. It must be called with the lock held.
+func (reg *Registry) shadow(r *http.Request, key interface{}) {
+	frames := reg.scopes[r]
+	if len(frames) == 0 {
+		return
+	}
+	frame := frames[len(frames)-1]
+	if _, ok := frame[key]; ok {
+		return
+	}
+	value, ok := reg.data[r][key]
+	if ok && reg.expired(r, key) {
+		value, ok = nil, false
+	}
+	frame[key] = shadowed{value: value, existed: ok, seq: reg.order[r][key]}
+}
@@ -0,0 +1,91 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestPushScope(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		t.Helper()
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	reg := New()
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer reg.Clear(r)
+	reg.Set(r, key1, "outer")
+	reg.Set(r, key2, "kept")
+
+	reg.PushScope(r)
+	reg.Set(r, key1, "inner")
+	reg.Set(r, key1, "inner2")
+	reg.Set(r, "key3", "new")
+	reg.Delete(r, key2)
+	assertEqual(reg.Get(r, key1), "inner2")
+
+	reg.PushScope(r)
+	reg.Set(r, "key3", "innermost")
+	reg.PopScope(r)
+	assertEqual(reg.Get(r, "key3"), "new")
+
+	reg.PopScope(r)
+	assertEqual(reg.Get(r, key1), "outer")
+	assertEqual(reg.Get(r, key2), "kept")
+	_, ok := reg.GetOk(r, "key3")
+	assertEqual(ok, false)
+
+	// Popping without a scope has no effect.
+	reg.PopScope(r)
+	assertEqual(reg.Get(r, key1), "outer")
+	assertEqual(len(reg.scopes), 0)
+}
+
+func TestPopScopeWrites(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		t.Helper()
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	var refused []error
+	reg := New(WithInsertionOrder(), WithHistory(), WithRefusedWrites(func(r *http.Request, err error) {
+		refused = append(refused, err)
+	}))
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer reg.Clear(r)
+	reg.Set(r, key1, "1")
+	reg.Set(r, key2, "2")
+
+	// Restored values keep their position, and are recorded.
+	reg.PushScope(r)
+	reg.Delete(r, key1)
+	reg.Set(r, "key3", "3")
+	reg.PopScope(r)
+	var keys []interface{}
+	reg.Range(r, func(key, val interface{}) bool {
+		keys = append(keys, key)
+		return true
+	})
+	assertEqual(len(keys), 2)
+	assertEqual(keys[0], key1)
+	assertEqual(keys[1], key2)
+	history := reg.History(r)
+	assertEqual(history[len(history)-1].Op, "PopScope")
+	assertEqual(history[len(history)-2].Op, "PopScope")
+
+	// Nothing is restored in frozen requests.
+	reg.PushScope(r)
+	reg.Set(r, key2, "changed")
+	reg.Freeze(r)
+	reg.PopScope(r)
+	assertEqual(len(refused), 1)
+	assertEqual(reg.Get(r, key2), "changed")
+}
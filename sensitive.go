@@ -0,0 +1,55 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"sync"
+)
+
+// Redacted replaces the values of sensitive keys in exports.
+const Redacted = "[REDACTED]"
+
+// sensitive holds the keys marked with MarkSensitive.
+var sensitive sync.Map
+
+// MarkSensitive marks keys whose values must not be exported, such as
+// credentials or personal data. The values are replaced with Redacted by
+// DebugHandler, History, the values given to HandlerOptions.OnPanic and
+// HandlerOptions.AfterResponse, and the other functions exporting values,
+// which makes it safe to enable them in production.
+//
+// Sensitive keys apply to all registries. Values are still stored and
+// returned by Get, GetAll and the like as usual.
+func MarkSensitive(keys ...interface{}) {
+	for _, k := range keys {
+		sensitive.Store(k, struct{}{})
+	}
+}
+
+// IsSensitive reports whether a key was marked with MarkSensitive.
+func IsSensitive(key interface{}) bool {
+	_, ok := sensitive.Load(key)
+	return ok
+}
+
+// Redact replaces the values of sensitive keys in values with Redacted,
+// and returns values. It's meant for the maps returned by GetAll, which
+// are copies.
+func Redact(values map[interface{}]interface{}) map[interface{}]interface{} {
+	for k := range values {
+		if IsSensitive(k) {
+			values[k] = Redacted
+		}
+	}
+	return values
+}
+
+// redactValue returns val, or Redacted if key is sensitive.
+func redactValue(key, val interface{}) interface{} {
+	if IsSensitive(key) {
+		return Redacted
+	}
+	return val
+}
@@ -0,0 +1,64 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type sensitiveKey int
+
+const passwordKey sensitiveKey = 0
+
+func TestMarkSensitive(t *testing.T) {
+	MarkSensitive(passwordKey)
+	if !IsSensitive(passwordKey) || IsSensitive(key1) {
+		t.Fatal("Unexpected IsSensitive results")
+	}
+
+	values := Redact(map[interface{}]interface{}{passwordKey: "secret", key1: "1"})
+	if values[passwordKey] != Redacted || values[key1] != "1" {
+		t.Errorf("Unexpected redacted values %v.", values)
+	}
+
+	// History
+	reg := New(WithHistory())
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	reg.Set(r, passwordKey, "secret")
+	if h := reg.History(r); len(h) != 1 || h[0].Value != Redacted {
+		t.Errorf("Unexpected history %+v.", h)
+	}
+	if v := reg.Get(r, passwordKey); v != "secret" {
+		t.Errorf("Expected the stored value, got %v.", v)
+	}
+
+	// DebugHandler
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?format=json", nil)
+	reg.DebugHandler().ServeHTTP(rec, req)
+	var entries []debugEntry
+	if err := json.NewDecoder(rec.Body).Decode(&entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Values["0 (context.sensitiveKey)"] != Redacted {
+		t.Errorf("Unexpected debug entries %+v.", entries)
+	}
+	reg.Clear(r)
+
+	// AfterResponse
+	var got map[interface{}]interface{}
+	h := ClearHandlerWithOptions(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Set(r, passwordKey, "secret")
+	}), HandlerOptions{AfterResponse: func(r *http.Request, values map[interface{}]interface{}) {
+		got = values
+	}})
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if got[passwordKey] != Redacted {
+		t.Errorf("Unexpected AfterResponse values %v.", got)
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package slogattr adds request values to log/slog records.
+//
+// Handler wraps a slog.Handler and appends the values stored for selected
+// keys to the records logged with a context bound to the request by
+// context.StdContext:
+//
+//	logger := slog.New(slogattr.NewHandler(slog.NewJSONHandler(os.Stderr, nil), map[interface{}]string{
+//		context.RequestIDKey: "request_id",
+//		userKey:              "user",
+//	}))
+//
+//	func h(w http.ResponseWriter, r *http.Request) {
+//		logger.InfoContext(context.StdContext(r), "serving")
+//		// ...
+//	}
+package slogattr
+
+import (
+	gocontext "context"
+	"log/slog"
+	"sort"
+
+	"github.com/gorilla/context"
+)
+
+// Handler is a slog.Handler appending request values to the records it
+// handles.
+type Handler struct {
+	h     slog.Handler
+	attrs []mapped
+}
+
+// mapped is an attribute name and the key of its value.
+type mapped struct {
+	key  interface{}
+	name string
+}
+
+// NewHandler returns a Handler passing records to h, with the values
+// stored for the keys of mapping appended as the attributes they map to.
+// Attributes are sorted by name; keys with no value are skipped, and the
+// values of sensitive keys are redacted, see context.MarkSensitive.
+//
+// Values are only appended to records logged with a context returned by
+// context.StdContext, or derived from one.
+func NewHandler(h slog.Handler, mapping map[interface{}]string) *Handler {
+	attrs := make([]mapped, 0, len(mapping))
+	for k, name := range mapping {
+		attrs = append(attrs, mapped{key: k, name: name})
+	}
+	sort.Slice(attrs, func(i, j int) bool {
+		return attrs[i].name < attrs[j].name
+	})
+	return &Handler{h: h, attrs: attrs}
+}
+
+// Enabled implements slog.Handler.
+func (h *Handler) Enabled(ctx gocontext.Context, level slog.Level) bool {
+	return h.h.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler.
+func (h *Handler) Handle(ctx gocontext.Context, rec slog.Record) error {
+	if r, ok := context.FromStdContext(ctx); ok {
+		rec = rec.Clone()
+		for _, a := range h.attrs {
+			value, ok := context.GetOk(r, a.key)
+			if !ok {
+				continue
+			}
+			if context.IsSensitive(a.key) {
+				value = context.Redacted
+			}
+			rec.AddAttrs(slog.Any(a.name, value))
+		}
+	}
+	return h.h.Handle(ctx, rec)
+}
+
+// WithAttrs implements slog.Handler.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &Handler{h: h.h.WithAttrs(attrs), attrs: h.attrs}
+}
+
+// WithGroup implements slog.Handler. Request values are appended to the
+// group too.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{h: h.h.WithGroup(name), attrs: h.attrs}
+}
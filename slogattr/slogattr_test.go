@@ -0,0 +1,64 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slogattr
+
+import (
+	"bytes"
+	gocontext "context"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/context"
+)
+
+type keyType int
+
+const secretKey keyType = 0
+
+func TestHandler(t *testing.T) {
+	var buf bytes.Buffer
+	text := slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	context.MarkSensitive(secretKey)
+	logger := slog.New(NewHandler(text, map[interface{}]string{
+		"user":    "user",
+		"retries": "retries",
+		"missing": "missing",
+		secretKey: "secret",
+	}))
+
+	r := httptest.NewRequest("GET", "/", nil)
+	defer context.Clear(r)
+	context.Set(r, "user", "alice")
+	context.Set(r, "retries", 2)
+	context.Set(r, secretKey, "hidden")
+
+	logger.InfoContext(context.StdContext(r), "serving")
+	logger.With("component", "db").InfoContext(context.StdContext(r), "query")
+	logger.InfoContext(gocontext.Background(), "unbound")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := []string{
+		`level=INFO msg=serving retries=2 secret=[REDACTED] user=alice`,
+		`level=INFO msg=query component=db retries=2 secret=[REDACTED] user=alice`,
+		`level=INFO msg=unbound`,
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("Unexpected output:\n%s", buf.String())
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("Expected %s, got %s", want[i], lines[i])
+		}
+	}
+}
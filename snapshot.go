@@ -0,0 +1,108 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"bytes"
+	"encoding/gob"
+	"net/http"
+	"sync/atomic"
+)
+
+// Snapshot is a copy of the values of a request, taken by TakeSnapshot and
+// stored back by Restore. It's meant for tests capturing the state of a
+// request at a failure point, to replay it against a handler in isolation.
+//
+// Snapshots can be encoded with encoding/gob, provided the concrete types
+// of the keys and values are registered with gob.Register.
+type Snapshot struct {
+	Values map[interface{}]interface{}
+}
+
+// snapshotEntry is a value of a Snapshot as encoded with gob.
+type snapshotEntry struct {
+	Key   interface{}
+	Value interface{}
+}
+
+// GobEncode implements gob.GobEncoder.
+func (s Snapshot) GobEncode() ([]byte, error) {
+	entries := make([]snapshotEntry, 0, len(s.Values))
+	for k, v := range s.Values {
+		entries = append(entries, snapshotEntry{Key: k, Value: v})
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(entries); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements gob.GobDecoder.
+func (s *Snapshot) GobDecode(b []byte) error {
+	var entries []snapshotEntry
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&entries); err != nil {
+		return err
+	}
+	s.Values = make(map[interface{}]interface{}, len(entries))
+	for _, e := range entries {
+		s.Values[e.Key] = e.Value
+	}
+	return nil
+}
+
+// TakeSnapshot returns a copy of the values stored for a given request.
+// Values stored with SetWithTTL are copied without their expiration.
+func (reg *Registry) TakeSnapshot(r *http.Request) Snapshot {
+	return Snapshot{Values: reg.GetAll(r)}
+}
+
+// TakeSnapshot returns a copy of the values stored for a given request.
+// Values stored with SetWithTTL are copied without their expiration.
+func TakeSnapshot(r *http.Request) Snapshot {
+	return Snapshot{Values: DefaultStore().GetAll(r)}
+}
+
+// Restore replaces the values stored for a given request with the values
+// of a snapshot. The request's OnClear functions are kept. Nothing is
+// changed if any of the writes is refused, see HandleRefusedWrites.
+func (reg *Registry) Restore(r *http.Request, s Snapshot) {
+	reg.lock()
+	r = reg.resolve(r)
+	keys := reg.keysExcept(r, nil)
+	for _, k := range keys {
+		if err := reg.checkWrite(r, k); err != nil {
+			reg.unlock()
+			reg.refuse(r, err)
+			return
+		}
+	}
+	values := make(map[interface{}]interface{}, len(s.Values))
+	for k, v := range s.Values {
+		v, err := reg.checkSet(r, k, v)
+		if err != nil {
+			reg.unlock()
+			reg.refuse(r, err)
+			return
+		}
+		values[k] = v
+	}
+	for _, k := range keys {
+		reg.del(r, k, "Delete")
+	}
+	reg.publish(r)
+	for k, v := range values {
+		atomic.AddUint64(&reg.counters.sets, 1)
+		reg.set(r, k, v)
+	}
+	reg.unlock()
+}
+
+// Restore replaces the values stored for a given request with the values
+// of a snapshot. The request's OnClear functions are kept. Nothing is
+// changed if any of the writes is refused, see HandleRefusedWrites.
+func Restore(r *http.Request, s Snapshot) {
+	defaultRegistry("Restore").Restore(r, s)
+}
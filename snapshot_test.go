@@ -0,0 +1,61 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"bytes"
+	"encoding/gob"
+	"net/http"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		t.Helper()
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+	Set(r, key1, "1")
+	Set(r, key2, 2)
+	s := TakeSnapshot(r)
+
+	// Snapshots survive gob encoding.
+	gob.Register(key1)
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(s); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Snapshot
+	if err := gob.NewDecoder(&buf).Decode(&decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	replay, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(replay)
+	Set(replay, "stale", true)
+	Restore(replay, decoded)
+	assertEqual(len(GetAll(replay)), 2)
+	assertEqual(Get(replay, key1), "1")
+	assertEqual(Get(replay, key2), 2)
+
+	// The snapshot is a copy.
+	Set(r, key1, "changed")
+	assertEqual(s.Values[key1], "1")
+
+	// Refused restores change nothing.
+	var refused error
+	reg := New(WithRefusedWrites(func(r *http.Request, err error) { refused = err }))
+	defer reg.Clear(replay)
+	reg.Set(replay, "stale", true)
+	reg.Freeze(replay)
+	reg.Restore(replay, s)
+	assertEqual(refused, ErrFrozen)
+	assertEqual(len(reg.GetAll(replay)), 1)
+	assertEqual(reg.Get(replay, "stale"), true)
+}
@@ -0,0 +1,149 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// lockSampleRate is the rate at which lock acquisitions are timed.
+const lockSampleRate = 64
+
+// StoreStats describes the state of the store at a point in time.
+type StoreStats struct {
+	// Requests is the amount of requests with stored values.
+	Requests int
+	// Values is the amount of values stored across all requests.
+	Values int
+	// ValuesPerRequest maps powers of two to the amount of requests with
+	// at least that many values, and less than twice as many. Requests
+	// with no values are counted under 0.
+	ValuesPerRequest map[int]int
+	// MaxValues is the most values a single request held since the
+	// program started.
+	MaxValues int
+
+	// Sets, Gets and Clears count the calls to the functions storing,
+	// reading and clearing values since the program started.
+	Sets   uint64
+	Gets   uint64
+	Clears uint64
+	// Purged counts the requests removed by Purge or by the janitor, and
+	// Purges the times they ran. LastPurge is the time of the last run, or
+	// the zero time.
+	Purged    uint64
+	Purges    uint64
+	LastPurge time.Time
+	// Evicted and Rejected count the requests removed to make room for new
+	// ones, and the new requests whose values were dropped, when the
+	// amount of requests is capped with WithMaxEntries.
+	Evicted  uint64
+	Rejected uint64
+
+	// LockWait is the average time spent waiting for the lock, estimated
+	// by timing one acquisition out of 64.
+	LockWait time.Duration
+}
+
+// Stats returns the current statistics of the registry.
+func (reg *Registry) Stats() StoreStats {
+	reg.rlock()
+	s := StoreStats{
+		Requests:         len(reg.data),
+		ValuesPerRequest: make(map[int]int),
+		MaxValues:        reg.maxValues,
+	}
+	for _, values := range reg.data {
+		s.Values += len(values)
+		s.ValuesPerRequest[valuesBucket(len(values))]++
+	}
+	if reg.lastPurge != 0 {
+		s.LastPurge = time.Unix(0, reg.lastPurge)
+	}
+	reg.mutex.RUnlock()
+	s.Sets = atomic.LoadUint64(&reg.counters.sets)
+	s.Gets = atomic.LoadUint64(&reg.counters.gets)
+	s.Clears = atomic.LoadUint64(&reg.counters.clears)
+	s.Purged = atomic.LoadUint64(&reg.counters.purged)
+	s.Purges = atomic.LoadUint64(&reg.counters.purges)
+	s.Evicted = atomic.LoadUint64(&reg.counters.evicted)
+	s.Rejected = atomic.LoadUint64(&reg.counters.rejected)
+	if n := atomic.LoadUint64(&reg.counters.lockSamples); n > 0 {
+		s.LockWait = time.Duration(atomic.LoadUint64(&reg.counters.lockWait) / n)
+	}
+	return s
+}
+
+// Stats returns the current statistics of the default store.
+func Stats() StoreStats {
+	return defaultRegistry("Stats").Stats()
+}
+
+// valuesBucket returns the power of two bucket of a request holding n
+// values.
+func valuesBucket(n int) int {
+	if n == 0 {
+		return 0
+	}
+	bucket := 1
+	for n > 1 {
+		n >>= 1
+		bucket <<= 1
+	}
+	return bucket
+}
+
+// observeSize records the size of the values of a request after a value
+// was stored for a given key, and checks its quota. It must be called with
+// the lock held.
+func (reg *Registry) observeSize(r *http.Request, key interface{}) {
+	n := len(reg.data[r])
+	if n > reg.maxValues {
+		reg.maxValues = n
+	}
+	if reg.quota != nil {
+		reg.checkQuota(r, key)
+	}
+}
+
+// lock locks the registry for writing, timing one acquisition out of
+// lockSampleRate.
+func (reg *Registry) lock() {
+	if atomic.AddUint64(&reg.counters.locks, 1)%lockSampleRate != 0 {
+		reg.mutex.Lock()
+		return
+	}
+	start := time.Now()
+	reg.mutex.Lock()
+	reg.sampleLock(start)
+}
+
+// unlock releases the lock taken with lock, then runs the functions the
+// holder of the lock queued in pending.
+func (reg *Registry) unlock() {
+	fns := reg.pending
+	reg.pending = nil
+	reg.mutex.Unlock()
+	runHooks(fns)
+}
+
+// rlock locks the registry for reading, timing one acquisition out of
+// lockSampleRate.
+func (reg *Registry) rlock() {
+	if atomic.AddUint64(&reg.counters.locks, 1)%lockSampleRate != 0 {
+		reg.mutex.RLock()
+		return
+	}
+	start := time.Now()
+	reg.mutex.RLock()
+	reg.sampleLock(start)
+}
+
+func (reg *Registry) sampleLock(start time.Time) {
+	atomic.AddUint64(&reg.counters.lockWait, uint64(time.Since(start)))
+	atomic.AddUint64(&reg.counters.lockSamples, 1)
+}
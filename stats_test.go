@@ -0,0 +1,96 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	r1, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	r2, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+
+	before := Stats()
+	Set(r1, key1, "1")
+	Set(r1, key2, "2")
+	Set(r2, key1, "1")
+	Get(r1, key1)
+	GetOk(r2, key1)
+
+	s := Stats()
+	assertEqual(s.Requests, before.Requests+2)
+	assertEqual(s.Values, before.Values+3)
+	assertEqual(s.Sets, before.Sets+3)
+	assertEqual(s.Gets, before.Gets+2)
+
+	Clear(r1)
+	builtin.mutex.Lock()
+	builtin.datat[r2] -= 120
+	builtin.mutex.Unlock()
+	Purge(60)
+
+	s = Stats()
+	assertEqual(s.Requests, before.Requests)
+	assertEqual(s.Clears, before.Clears+1)
+	assertEqual(s.Purged, before.Purged+1)
+}
+
+func TestStatsExtended(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	reg := New()
+	r1, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	r2, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	for k := 0; k < 3; k++ {
+		reg.Set(r1, keyType(k), k)
+	}
+	reg.Set(r2, key1, "1")
+	reg.Handle(r2).Set(key2, "2")
+	reg.OnClear(r2, func() {})
+	reg.Delete(r2, key1)
+	reg.Delete(r2, key2)
+
+	s := reg.Stats()
+	assertEqual(s.MaxValues, 3)
+	assertEqual(len(s.ValuesPerRequest), 2)
+	assertEqual(s.ValuesPerRequest[2], 1)
+	assertEqual(s.ValuesPerRequest[0], 1)
+	assertEqual(s.Purges, uint64(0))
+	assertEqual(s.LastPurge.IsZero(), true)
+
+	reg.Clear(r1)
+	reg.Purge(0)
+	s = reg.Stats()
+	assertEqual(s.MaxValues, 3)
+	assertEqual(s.Purges, uint64(1))
+	assertEqual(s.LastPurge.IsZero(), false)
+
+	for i := 0; i < 2*lockSampleRate; i++ {
+		reg.Get(r1, key1)
+	}
+	if n := atomic.LoadUint64(&reg.counters.lockSamples); n == 0 {
+		t.Error("Expected sampled lock acquisitions")
+	}
+}
+
+func TestValuesBucket(t *testing.T) {
+	for n, exp := range map[int]int{0: 0, 1: 1, 2: 2, 3: 2, 4: 4, 7: 4, 8: 8, 100: 64} {
+		if got := valuesBucket(n); got != exp {
+			t.Errorf("Expected bucket %d for %d, got %d.", exp, n, got)
+		}
+	}
+}
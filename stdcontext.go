@@ -0,0 +1,51 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	gocontext "context"
+	"net/http"
+)
+
+type stdContextKey int
+
+// requestKey is used by FromStdContext to find the request bound by
+// StdContext.
+const requestKey stdContextKey = 0
+
+// stdContext is a context.Context whose values are looked up in the
+// request values first.
+type stdContext struct {
+	gocontext.Context
+	r *http.Request
+}
+
+func (c *stdContext) Value(key interface{}) interface{} {
+	if key == requestKey {
+		return c.r
+	}
+	if value, ok := GetOk(c.r, key); ok {
+		return value
+	}
+	return c.Context.Value(key)
+}
+
+// StdContext returns a context.Context derived from r.Context() whose
+// Value method returns the values stored for the request, falling back to
+// the values of r.Context() for keys not stored.
+//
+// Values are looked up on every call, so values set after StdContext
+// returns are visible too.
+func StdContext(r *http.Request) gocontext.Context {
+	return &stdContext{Context: r.Context(), r: r}
+}
+
+// FromStdContext returns the request bound to a context returned by
+// StdContext, or any context derived from it. The context of outgoing
+// requests prepared by ProxyDirector is bound to them too.
+func FromStdContext(ctx gocontext.Context) (*http.Request, bool) {
+	r, ok := ctx.Value(requestKey).(*http.Request)
+	return r, ok
+}
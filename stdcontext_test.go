@@ -0,0 +1,43 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	gocontext "context"
+	"net/http"
+	"testing"
+)
+
+func TestStdContext(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	r = r.WithContext(gocontext.WithValue(r.Context(), key2, "parent"))
+	defer Clear(r)
+
+	ctx := StdContext(r)
+	assertEqual(ctx.Value(key1), nil)
+	assertEqual(ctx.Value(key2), "parent")
+
+	// Values stored later are visible, and shadow the parent's.
+	Set(r, key1, "1")
+	Set(r, key2, "2")
+	assertEqual(ctx.Value(key1), "1")
+	assertEqual(ctx.Value(key2), "2")
+
+	// FromStdContext()
+	derived := gocontext.WithValue(ctx, "other", "value")
+	got, ok := FromStdContext(derived)
+	assertEqual(got, r)
+	assertEqual(ok, true)
+
+	got, ok = FromStdContext(gocontext.Background())
+	assertEqual(got, (*http.Request)(nil))
+	assertEqual(ok, false)
+}
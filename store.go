@@ -0,0 +1,268 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"container/list"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Store is the storage used by the package-level functions. Its methods
+// behave like the functions with the same name.
+//
+// Stores must be safe for concurrent use.
+type Store interface {
+	Set(r *http.Request, key, val interface{})
+	Get(r *http.Request, key interface{}) interface{}
+	GetOk(r *http.Request, key interface{}) (interface{}, bool)
+	// GetAll returns nil if no values are stored for the request.
+	GetAll(r *http.Request) map[interface{}]interface{}
+	Delete(r *http.Request, key interface{})
+	Clear(r *http.Request)
+	Purge(maxAge int) int
+}
+
+// Registry is the default Store implementation: a map of request values
+// guarded by a lock. It also implements the functionality that goes beyond
+// the Store interface, such as OnClear or SetWithTTL.
+//
+// The package-level functions use a default Registry shared by the whole
+// program. Libraries can create their own with New, so that their values
+// and lifecycle are independent from other packages.
+//
+// The values, creation times and OnClear functions of the requests, and
+// the lock guarding the registry, are those of a Scope[*http.Request].
+type Registry struct {
+	// counters are updated atomically, so that Get doesn't need the write
+	// lock. They come first to be 64-bit aligned.
+	counters struct {
+		sets   uint64
+		gets   uint64
+		clears uint64
+		purged uint64
+		purges uint64
+		// evicted and rejected count the requests removed or refused
+		// because of WithMaxEntries.
+		evicted  uint64
+		rejected uint64
+		// locks counts lock acquisitions, of which one in lockSampleRate
+		// is timed.
+		locks       uint64
+		lockSamples uint64
+		lockWait    uint64
+	}
+
+	requestScope
+	// pending holds the functions to run once the lock is released, see
+	// unlock.
+	pending []func()
+	// maxValues is the most values a request held, and lastPurge the Unix
+	// time in nanoseconds of the last purge.
+	maxValues int
+	lastPurge int64
+
+	// links maps request clones to the request they share values with,
+	// and clones is the reverse index.
+	links  map[*http.Request]*http.Request
+	clones map[*http.Request][]*http.Request
+	// expires holds the expiration time of values stored with SetWithTTL.
+	expires map[*http.Request]map[interface{}]time.Time
+	// watchers holds the channels returned by Watch.
+	watchers map[*http.Request]map[interface{}][]*watcher
+	// flights holds the calls to Do in flight, and memos the results
+	// cached by Memoize, apart from the values.
+	flights map[*http.Request]map[interface{}]*flight
+	memos   map[*http.Request]map[interface{}]*memo
+	// retains holds the requests held with Retain.
+	retains map[*http.Request]*retain
+	// pool holds the value maps of cleared requests, for reuse.
+	pool sync.Pool
+	// snapshots holds the copies of the values read without the lock, when
+	// copy-on-write is enabled.
+	cow       bool
+	snapshots sync.Map
+
+	// maxEntries caps the amount of requests, enforced by policy. The
+	// requests are kept in registration order in arrivals, arrival holding
+	// their element, for EvictOldest and PurgeStep.
+	maxEntries int
+	policy     Policy
+	arrivals   *list.List
+	arrival    map[*http.Request]*list.Element
+	quota      *Quota
+	// evicting holds the requests evicted for new ones whose OnClear
+	// functions are running. Nothing new is stored for them.
+	evicting map[*http.Request]struct{}
+	// access holds the Unix time in seconds of the last access to each
+	// request, updated atomically, when idle purging is enabled.
+	idle   bool
+	access map[*http.Request]*int64
+	// cursor is the element of arrivals PurgeStep examines next in its
+	// current pass, or nil once the pass completed. It moves past the
+	// requests cleared before being examined.
+	cursor *list.Element
+	// purgeReport is called for each request removed by purging.
+	purgeReport func(PurgedRequest)
+
+	// strict is called on use of a cleared request, and cleared holds the
+	// Unix time in seconds cleared requests were cleared at.
+	strict  func(UseAfterClear)
+	cleared map[*http.Request]int64
+
+	// keyStats is set, atomically, when keyCounters holds a *keyCounter
+	// per key.
+	keyStats    int32
+	keyCounters sync.Map
+
+	// traceTasks holds the runtime/trace tasks of the requests served by
+	// TraceTaskHandler.
+	traceTasks map[*http.Request]*traceTask
+
+	// scopes holds the values shadowed by the scopes pushed with
+	// PushScope, innermost last.
+	scopes map[*http.Request][]map[interface{}]shadowed
+	// frozen holds the requests frozen with Freeze, and refused handles
+	// the writes refused to them.
+	frozen  map[*http.Request]bool
+	refused func(r *http.Request, err error)
+	// defaults holds the values set with SetGlobalDefault.
+	defaults sync.Map
+
+	// history holds the mutations of each request, when enabled.
+	history map[*http.Request][]Mutation
+	// origins holds the caller that stored each value, when enabled.
+	origins map[*http.Request]map[interface{}]string
+	// order holds the insertion sequence number of each key, when
+	// insertion order is enabled, and orderSeq the last number used.
+	order    map[*http.Request]map[interface{}]uint64
+	orderSeq uint64
+	// clocks holds the clocks set with SetClock.
+	clocks map[*http.Request]Clock
+
+	leakReport func(Leak)
+	leakGrace  time.Duration
+	// stacks holds the stack traces captured for leak reports, or for
+	// Leaks when captureStacks is set.
+	stacks        map[*http.Request][]byte
+	captureStacks bool
+}
+
+// requestScope is embedded in Registry, under an unexported name.
+type requestScope = Scope[*http.Request]
+
+// Option configures a Registry created by New.
+type Option func(*Registry)
+
+// WithLeakDetection enables leak detection for the registry, like
+// DetectLeaks.
+func WithLeakDetection(grace time.Duration, fn func(Leak)) Option {
+	return func(reg *Registry) {
+		reg.leakReport = fn
+		reg.leakGrace = grace
+	}
+}
+
+// WithCopyOnWrite makes Get and GetOk read values without locking the
+// registry, from a copy of the values of the request that every write
+// replaces. It speeds up requests read concurrently by many goroutines, at
+// the cost of copying all the values of a request on each write.
+//
+// Values stored with SetWithTTL still take the locked path.
+func WithCopyOnWrite() Option {
+	return func(reg *Registry) {
+		reg.cow = true
+	}
+}
+
+// New returns a new Registry configured with the given options.
+func New(opts ...Option) *Registry {
+	reg := &Registry{
+		links:      make(map[*http.Request]*http.Request),
+		clones:     make(map[*http.Request][]*http.Request),
+		expires:    make(map[*http.Request]map[interface{}]time.Time),
+		watchers:   make(map[*http.Request]map[interface{}][]*watcher),
+		flights:    make(map[*http.Request]map[interface{}]*flight),
+		memos:      make(map[*http.Request]map[interface{}]*memo),
+		retains:    make(map[*http.Request]*retain),
+		access:     make(map[*http.Request]*int64),
+		stacks:     make(map[*http.Request][]byte),
+		traceTasks: make(map[*http.Request]*traceTask),
+		scopes:     make(map[*http.Request][]map[interface{}]shadowed),
+		frozen:     make(map[*http.Request]bool),
+		arrivals:   list.New(),
+		arrival:    make(map[*http.Request]*list.Element),
+		evicting:   make(map[*http.Request]struct{}),
+	}
+	reg.requestScope.init()
+	for _, opt := range opts {
+		opt(reg)
+	}
+	return reg
+}
+
+// Len returns the number of requests with stored values.
+func (reg *Registry) Len() int {
+	reg.rlock()
+	defer reg.mutex.RUnlock()
+	return len(reg.data)
+}
+
+// builtin is the Registry used by default.
+var builtin = New()
+
+// defaultStore holds a storeHolder, as atomic.Value requires a consistent
+// concrete type.
+var defaultStore atomic.Value
+
+type storeHolder struct {
+	Store
+}
+
+func init() {
+	defaultStore.Store(storeHolder{builtin})
+}
+
+// DefaultStore returns the Store used by the package-level functions.
+func DefaultStore() Store {
+	return defaultStore.Load().(storeHolder).Store
+}
+
+// SetDefaultStore replaces the Store used by the package-level functions.
+// Values stored in the previous Store are not moved.
+//
+// The functions that are not part of the Store interface, such as OnClear
+// or SetWithTTL, require the default store to be a *Registry, or a type
+// embedding one, and panic otherwise.
+func SetDefaultStore(s Store) {
+	defaultStore.Store(storeHolder{s})
+}
+
+// registry returns reg. Being promoted to the types embedding a *Registry,
+// it lets such stores support the functions that require a *Registry.
+func (reg *Registry) registry() *Registry {
+	return reg
+}
+
+// rawValues returns the values stored for a given request in s, or nil if
+// none, without computing those stored with SetLazy when s is a *Registry.
+func rawValues(s Store, r *http.Request) map[interface{}]interface{} {
+	if s, ok := s.(interface{ registry() *Registry }); ok {
+		return s.registry().rawValues(r)
+	}
+	return s.GetAll(r)
+}
+
+// defaultRegistry returns the default store for a function that requires
+// a *Registry.
+func defaultRegistry(name string) *Registry {
+	if s, ok := DefaultStore().(interface{ registry() *Registry }); ok {
+		return s.registry()
+	}
+	panic(fmt.Sprintf("context: %s requires the default store to be a *Registry, not %T", name, DefaultStore()))
+}
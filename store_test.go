@@ -0,0 +1,99 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// countingStore is a Store counting the calls to Set.
+type countingStore struct {
+	Store
+	sets int
+}
+
+func (s *countingStore) Set(r *http.Request, key, val interface{}) {
+	s.sets++
+	s.Store.Set(r, key, val)
+}
+
+func TestSetDefaultStore(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+
+	if DefaultStore() != Store(builtin) {
+		t.Fatal("Default store isn't the built-in registry")
+	}
+
+	s := &countingStore{Store: New()}
+	SetDefaultStore(s)
+	defer SetDefaultStore(builtin)
+
+	Set(r, key1, "1")
+	assertEqual(s.sets, 1)
+	assertEqual(Get(r, key1), "1")
+	values, ok := GetAllOk(r)
+	assertEqual(len(values), 1)
+	assertEqual(ok, true)
+
+	// The built-in registry isn't used.
+	assertEqual(builtin.Get(r, key1), nil)
+
+	Clear(r)
+	values, ok = GetAllOk(r)
+	assertEqual(len(values), 0)
+	assertEqual(ok, false)
+
+	// Functions beyond the Store interface require a *Registry.
+	defer func() {
+		if recover() == nil {
+			t.Error("OnClear didn't panic with a custom store")
+		}
+	}()
+	OnClear(r, func() {})
+}
+
+func TestNew(t *testing.T) {
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+
+	reg1 := New()
+	reg2 := New()
+	Set(r, key1, "default")
+	reg1.Set(r, key1, "1")
+	reg2.Set(r, key1, "2")
+
+	// Registries are independent from each other.
+	reg1.Clear(r)
+	if v := reg1.Get(r, key1); v != nil {
+		t.Errorf("Expected nil, got %v.", v)
+	}
+	if v := reg2.Get(r, key1); v != "2" {
+		t.Errorf("Expected 2, got %v.", v)
+	}
+	if v := Get(r, key1); v != "default" {
+		t.Errorf("Expected default, got %v.", v)
+	}
+	if n := reg2.Len(); n != 1 {
+		t.Errorf("Expected 1 request, got %d.", n)
+	}
+	reg2.Clear(r)
+	if n := reg2.Len(); n != 0 {
+		t.Errorf("Expected no request, got %d.", n)
+	}
+
+	// Options are applied.
+	reg := New(WithLeakDetection(time.Second, func(Leak) {}))
+	if reg.leakReport == nil || reg.leakGrace != time.Second {
+		t.Error("WithLeakDetection wasn't applied")
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+)
+
+// UseAfterClear describes a call to Set, Get or GetOk for a request that
+// was already cleared.
+type UseAfterClear struct {
+	// Request is the cleared request.
+	Request *http.Request
+	// Op is the name of the function called, and Key its key.
+	Op  string
+	Key interface{}
+	// Stack is the stack trace of the call.
+	Stack []byte
+}
+
+// Error implements the error interface, so that violations can be passed
+// to panic.
+func (v UseAfterClear) Error() string {
+	return fmt.Sprintf("context: %s(%v) on a cleared request", v.Op, v.Key)
+}
+
+// WithStrictMode enables strict mode for the registry, like StrictMode.
+func WithStrictMode(fn func(UseAfterClear)) Option {
+	return func(reg *Registry) {
+		reg.strict = fn
+	}
+}
+
+// StrictMode enables strict mode: fn is called when Set, Get or GetOk is
+// used for a request that was already cleared, typically by a goroutine
+// outliving a handler wrapped by ClearHandler. Such calls otherwise fail
+// silently: Get returns nil, and the values stored by Set are leaked until
+// purged. Passing a nil fn disables strict mode.
+//
+// fn is called after the operation, outside of the registry lock. To make
+// violations fatal, panic:
+//
+//	context.StrictMode(func(v context.UseAfterClear) { panic(v) })
+//
+// Strict mode remembers cleared requests until Purge removes them like
+// other request data, and is meant for tests and staging environments.
+// Only requests cleared after the call are checked.
+func (reg *Registry) StrictMode(fn func(UseAfterClear)) {
+	reg.lock()
+	reg.strict = fn
+	if fn == nil {
+		reg.cleared = nil
+	}
+	reg.unlock()
+}
+
+// StrictMode enables strict mode: fn is called when Set, Get or GetOk is
+// used for a request that was already cleared. Passing a nil fn disables
+// strict mode.
+//
+// See (*Registry).StrictMode for details.
+func StrictMode(fn func(UseAfterClear)) {
+	defaultRegistry("StrictMode").StrictMode(fn)
+}
+
+// markCleared remembers that a given request was cleared, in strict mode.
+// It must be called with the lock held.
+func (reg *Registry) markCleared(r *http.Request) {
+	if reg.strict == nil {
+		return
+	}
+	if reg.cleared == nil {
+		reg.cleared = make(map[*http.Request]int64)
+	}
+	reg.cleared[r] = now().Unix()
+}
+
+// usedAfterClear reports whether a given unregistered request was cleared,
+// in strict mode. It must be called with the lock held, for reading at
+// least.
+func (reg *Registry) usedAfterClear(r *http.Request) bool {
+	if reg.strict == nil || reg.data[r] != nil {
+		return false
+	}
+	_, ok := reg.cleared[r]
+	return ok
+}
+
+// reportUseAfterClear calls the strict mode function for a violation. It
+// must be called without the lock held.
+func (reg *Registry) reportUseAfterClear(r *http.Request, op string, key interface{}) {
+	reg.rlock()
+	fn := reg.strict
+	reg.mutex.RUnlock()
+	if fn != nil {
+		fn(UseAfterClear{Request: r, Op: op, Key: key, Stack: debug.Stack()})
+	}
+}
+
+// purgeCleared forgets the cleared requests older than min, a Unix time in
+// seconds. It must be called with the lock held.
+func (reg *Registry) purgeCleared(min int64) {
+	for r, t := range reg.cleared {
+		if t < min {
+			delete(reg.cleared, r)
+		}
+	}
+}
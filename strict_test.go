@@ -0,0 +1,66 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestStrictMode(t *testing.T) {
+	var got []UseAfterClear
+	reg := New(WithStrictMode(func(v UseAfterClear) {
+		got = append(got, v)
+	}))
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	other, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+
+	reg.Set(r, key1, "1")
+	reg.Get(other, key1)
+	reg.Clear(r)
+	if len(got) != 0 {
+		t.Fatalf("Unexpected violations %v.", got)
+	}
+
+	reg.Get(r, key1)
+	reg.GetOk(r, key2)
+	reg.Set(r, key1, "2")
+	if len(got) != 3 {
+		t.Fatalf("Expected 3 violations, got %v.", got)
+	}
+	for i, op := range []string{"Get", "GetOk", "Set"} {
+		if got[i].Op != op || got[i].Request != r || len(got[i].Stack) == 0 {
+			t.Errorf("Unexpected violation %+v.", got[i])
+		}
+	}
+	if got[2].Error() != "context: Set(0) on a cleared request" {
+		t.Errorf("Unexpected error %q.", got[2].Error())
+	}
+
+	// Purging forgets cleared requests.
+	reg.Purge(0)
+	reg.Get(r, key1)
+	if len(got) != 3 {
+		t.Errorf("Unexpected violations %v.", got[3:])
+	}
+}
+
+func TestStrictModePanic(t *testing.T) {
+	StrictMode(func(v UseAfterClear) { panic(v) })
+
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	Set(r, key1, "1")
+	Clear(r)
+	defer func() {
+		if _, ok := recover().(UseAfterClear); !ok {
+			t.Error("Expected a UseAfterClear panic")
+		}
+		// The registry isn't left locked.
+		StrictMode(nil)
+		Set(r, key1, "1")
+		Clear(r)
+	}()
+	Get(r, key1)
+}
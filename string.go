@@ -0,0 +1,73 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxStringValue is the length past which String truncates values.
+const maxStringValue = 64
+
+// String returns a one-line summary of the values stored for a given
+// request, as key=value pairs sorted like SortedKeys, meant for panic
+// messages and wrapped errors:
+//
+//	panic(fmt.Sprintf("unexpected state: %s", reg.String(r)))
+//
+// Values are formatted with fmt.Sprint, so that fmt.Stringer and error
+// values print nicely, quoted when they contain spaces, and truncated past
+// 64 bytes. The values of sensitive keys are replaced with Redacted, see
+// MarkSensitive.
+func (reg *Registry) String(r *http.Request) string {
+	return valuesString(reg.GetAll(r))
+}
+
+// String returns a one-line summary of the values stored for a given
+// request, as key=value pairs sorted like SortedKeys, meant for panic
+// messages and wrapped errors:
+//
+//	return fmt.Errorf("loading account: %w (%s)", err, context.String(r))
+//
+// Values are formatted with fmt.Sprint, so that fmt.Stringer and error
+// values print nicely, quoted when they contain spaces, and truncated past
+// 64 bytes. The values of sensitive keys are replaced with Redacted, see
+// MarkSensitive.
+func String(r *http.Request) string {
+	return valuesString(DefaultStore().GetAll(r))
+}
+
+func valuesString(values map[interface{}]interface{}) string {
+	keys := make([]interface{}, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sortKeys(keys)
+	var b strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(quoteString(fmt.Sprint(k)))
+		b.WriteByte('=')
+		b.WriteString(quoteString(fmt.Sprint(redactValue(k, values[k]))))
+	}
+	return b.String()
+}
+
+// quoteString truncates s, and quotes it if it contains spaces, quotes,
+// an equal sign or non-printable characters.
+func quoteString(s string) string {
+	if len(s) > maxStringValue {
+		s = s[:maxStringValue] + "..."
+	}
+	if s == "" || strings.ContainsAny(s, " \"=") || strconv.Quote(s) != `"`+s+`"` {
+		return strconv.Quote(s)
+	}
+	return s
+}
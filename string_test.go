@@ -0,0 +1,45 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type stringerValue struct{ id int }
+
+func (v stringerValue) String() string {
+	return fmt.Sprintf("user-%d", v.id)
+}
+
+func TestString(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		t.Helper()
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	MarkSensitive(passwordKey)
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+	assertEqual(String(r), "")
+
+	Set(r, "user", stringerValue{7})
+	Set(r, "err", errors.New("not found"))
+	Set(r, "count", 3)
+	Set(r, "empty", "")
+	Set(r, passwordKey, "hunter2")
+	assertEqual(String(r), `0=[REDACTED] count=3 empty="" err="not found" user=user-7`)
+
+	Set(r, "long", strings.Repeat("a", 100))
+	if s := String(r); !strings.Contains(s, "long="+strings.Repeat("a", 64)+"...") {
+		t.Errorf("Expected a truncated value, got %s.", s)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"math"
+	"net/http"
+	"sync"
+)
+
+// SyncMapStore is a Store backed by sync.Map instead of a map guarded by a
+// lock. It's meant to be installed with SetDefaultStore.
+//
+// It's faster than a Registry for values written once and read by many
+// goroutines, and slower when values are written often: sync.Map is
+// optimized for keys that are rarely replaced, and each request costs more
+// memory. Values stored concurrently with Clear for the same request may
+// be lost.
+//
+// Being a custom Store rather than a Registry option, it only supports the
+// package functions of the Store interface, such as Set, Get and Clear,
+// and those built on them, such as ClearHandler. Once it's the default
+// store, the functions that require a *Registry, such as OnClear,
+// SetWithTTL, SetLazy or Freeze, panic.
+type SyncMapStore struct {
+	requests sync.Map // *http.Request -> *syncMapEntry
+}
+
+// syncMapEntry holds the values of a request.
+type syncMapEntry struct {
+	values  sync.Map
+	created int64
+}
+
+// NewSyncMapStore returns a new, empty SyncMapStore.
+func NewSyncMapStore() *SyncMapStore {
+	return &SyncMapStore{}
+}
+
+// entry returns the entry of a given request, or nil.
+func (s *SyncMapStore) entry(r *http.Request) *syncMapEntry {
+	if e, ok := s.requests.Load(r); ok {
+		return e.(*syncMapEntry)
+	}
+	return nil
+}
+
+// Set stores a value for a given key in a given request.
+func (s *SyncMapStore) Set(r *http.Request, key, val interface{}) {
+	e := s.entry(r)
+	if e == nil {
+		v, _ := s.requests.LoadOrStore(r, &syncMapEntry{created: now().Unix()})
+		e = v.(*syncMapEntry)
+	}
+	e.values.Store(key, val)
+}
+
+// Get returns a value stored for a given key in a given request.
+func (s *SyncMapStore) Get(r *http.Request, key interface{}) interface{} {
+	value, _ := s.GetOk(r, key)
+	return value
+}
+
+// GetOk returns stored value and presence state like multi-value return of map access.
+func (s *SyncMapStore) GetOk(r *http.Request, key interface{}) (interface{}, bool) {
+	if e := s.entry(r); e != nil {
+		return e.values.Load(key)
+	}
+	return nil, false
+}
+
+// GetAll returns all stored values for the request as a map. Nil is returned for invalid requests.
+func (s *SyncMapStore) GetAll(r *http.Request) map[interface{}]interface{} {
+	e := s.entry(r)
+	if e == nil {
+		return nil
+	}
+	result := make(map[interface{}]interface{})
+	e.values.Range(func(k, v interface{}) bool {
+		result[k] = v
+		return true
+	})
+	return result
+}
+
+// Delete removes a value stored for a given key in a given request.
+func (s *SyncMapStore) Delete(r *http.Request, key interface{}) {
+	if e := s.entry(r); e != nil {
+		e.values.Delete(key)
+	}
+}
+
+// Clear removes all values stored for a given request.
+func (s *SyncMapStore) Clear(r *http.Request) {
+	s.requests.Delete(r)
+}
+
+// Purge removes request data stored for longer than maxAge, in seconds.
+// It returns the amount of requests removed.
+//
+// If maxAge <= 0, all request data is removed.
+func (s *SyncMapStore) Purge(maxAge int) int {
+	min := int64(math.MaxInt64)
+	if maxAge > 0 {
+		min = now().Unix() - int64(maxAge)
+	}
+	count := 0
+	s.requests.Range(func(r, e interface{}) bool {
+		if e.(*syncMapEntry).created < min {
+			s.requests.Delete(r)
+			count++
+		}
+		return true
+	})
+	return count
+}
@@ -0,0 +1,93 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestSyncMapStore(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	var s Store = NewSyncMapStore()
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+
+	assertEqual(s.Get(r, key1), nil)
+	assertEqual(s.GetAll(r) == nil, true)
+
+	s.Set(r, key1, "1")
+	s.Set(r, key2, "2")
+	assertEqual(s.Get(r, key1), "1")
+	val, ok := s.GetOk(r, key2)
+	assertEqual(val, "2")
+	assertEqual(ok, true)
+	assertEqual(len(s.GetAll(r)), 2)
+
+	s.Delete(r, key2)
+	_, ok = s.GetOk(r, key2)
+	assertEqual(ok, false)
+
+	s.Clear(r)
+	assertEqual(s.GetAll(r) == nil, true)
+
+	s.Set(r, key1, "1")
+	assertEqual(s.Purge(10), 0)
+	assertEqual(s.Purge(0), 1)
+	assertEqual(s.Get(r, key1), nil)
+
+	// It works as the default store for the Store based functions.
+	SetDefaultStore(s)
+	defer SetDefaultStore(builtin)
+	Set(r, key1, "default")
+	assertEqual(Get(r, key1), "default")
+	Clear(r)
+
+	// The other functions panic, naming the store.
+	defer func() {
+		msg, _ := recover().(string)
+		assertEqual(msg, "context: OnClear requires the default store to be a *Registry, not *context.SyncMapStore")
+	}()
+	OnClear(r, func() {})
+}
+
+func benchmarkStoreGetParallel(b *testing.B, s Store) {
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	s.Set(r, key1, "1")
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			s.Get(r, key1)
+		}
+	})
+}
+
+func benchmarkStoreSetClear(b *testing.B, s Store) {
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		s.Set(r, key1, i)
+		s.Set(r, key2, i)
+		s.Clear(r)
+	}
+}
+
+func BenchmarkRegistryGetParallel(b *testing.B) {
+	benchmarkStoreGetParallel(b, New())
+}
+func BenchmarkSyncMapStoreGetParallel(b *testing.B) {
+	benchmarkStoreGetParallel(b, NewSyncMapStore())
+}
+func BenchmarkRegistrySetClear(b *testing.B) {
+	benchmarkStoreSetClear(b, New())
+}
+func BenchmarkSyncMapStoreSetClear(b *testing.B) {
+	benchmarkStoreSetClear(b, NewSyncMapStore())
+}
@@ -0,0 +1,115 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"sync"
+)
+
+// keyInfos holds the *keyInfo of the keys registered with RegisterKey.
+var keyInfos sync.Map
+
+// keyInfo holds the options of a key registered with RegisterKey.
+type keyInfo struct {
+	tags   map[string]bool
+	cloner func(interface{}) interface{}
+	local  bool
+}
+
+// KeyOption configures a key registered with RegisterKey.
+type KeyOption func(*keyInfo)
+
+// Tags returns a KeyOption adding tags to a key, such as "auth" or "pii".
+func Tags(tags ...string) KeyOption {
+	return func(info *keyInfo) {
+		for _, t := range tags {
+			info.tags[t] = true
+		}
+	}
+}
+
+// RegisterKey registers a key with the given options, such as Tags, Cloner
+// or Local. The values of keys sharing a tag can be handled together with
+// DeleteByTag and GetAllByTag: policies such as stripping personal data
+// before an export, or dropping the credentials on logout, then don't need
+// to list the keys.
+// Registering a key again replaces its options.
+//
+// Registered keys apply to all registries.
+func RegisterKey(key interface{}, opts ...KeyOption) {
+	info := &keyInfo{tags: make(map[string]bool)}
+	for _, opt := range opts {
+		opt(info)
+	}
+	keyInfos.Store(key, info)
+}
+
+// lookupKey returns the options of a key registered with RegisterKey, or
+// nil.
+func lookupKey(key interface{}) *keyInfo {
+	if info, ok := keyInfos.Load(key); ok {
+		return info.(*keyInfo)
+	}
+	return nil
+}
+
+// hasTag reports whether a key was registered with a given tag.
+func hasTag(key interface{}, tag string) bool {
+	info := lookupKey(key)
+	return info != nil && info.tags[tag]
+}
+
+// DeleteByTag removes the values stored for a given request for the keys
+// registered with a given tag, and returns the amount of values removed.
+// Nothing is removed if the deletion of any of them is refused, see
+// Freeze and MarkImmutable.
+func (reg *Registry) DeleteByTag(r *http.Request, tag string) int {
+	reg.lock()
+	r = reg.resolve(r)
+	var keys []interface{}
+	for k := range reg.data[r] {
+		if hasTag(k, tag) {
+			keys = append(keys, k)
+		}
+	}
+	return reg.deleteAndUnlock(r, keys)
+}
+
+// GetAllByTag returns the values stored for a given request for the keys
+// registered with a given tag. Nil is returned for invalid requests.
+func (reg *Registry) GetAllByTag(r *http.Request, tag string) map[interface{}]interface{} {
+	reg.rlock()
+	r = reg.resolve(r)
+	reg.touch(r)
+	ctx, ok := reg.data[r]
+	if !ok {
+		reg.mutex.RUnlock()
+		return nil
+	}
+	result := make(map[interface{}]interface{})
+	for k, v := range ctx {
+		if hasTag(k, tag) && !reg.expired(r, k) {
+			result[k] = v
+		}
+	}
+	reg.mutex.RUnlock()
+	forceAll(result)
+	return result
+}
+
+// DeleteByTag removes the values stored for a given request for the keys
+// registered with a given tag, and returns the amount of values removed.
+// Nothing is removed if the deletion of any of them is refused, see
+// Freeze and MarkImmutable.
+func DeleteByTag(r *http.Request, tag string) int {
+	return defaultRegistry("DeleteByTag").DeleteByTag(r, tag)
+}
+
+// GetAllByTag returns the values stored for a given request for the keys
+// registered with a given tag. Nil is returned for invalid requests.
+func GetAllByTag(r *http.Request, tag string) map[interface{}]interface{} {
+	return defaultRegistry("GetAllByTag").GetAllByTag(r, tag)
+}
@@ -0,0 +1,57 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+type tagKey int
+
+const (
+	tagUserKey tagKey = iota
+	tagEmailKey
+	tagTokenKey
+)
+
+func TestTags(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		t.Helper()
+		if !reflect.DeepEqual(val, exp) {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	RegisterKey(tagUserKey, Tags("auth"))
+	RegisterKey(tagEmailKey, Tags("pii"), Tags("export"))
+	RegisterKey(tagTokenKey, Tags("auth", "pii"))
+
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+	assertEqual(GetAllByTag(r, "pii"), map[interface{}]interface{}(nil))
+
+	Set(r, tagUserKey, "alice")
+	Set(r, tagEmailKey, "alice@example.com")
+	Set(r, tagTokenKey, "secret")
+	Set(r, key1, "1")
+
+	assertEqual(GetAllByTag(r, "pii"), map[interface{}]interface{}{
+		tagEmailKey: "alice@example.com",
+		tagTokenKey: "secret",
+	})
+	assertEqual(GetAllByTag(r, "unknown"), map[interface{}]interface{}{})
+
+	assertEqual(DeleteByTag(r, "auth"), 2)
+	assertEqual(GetAll(r), map[interface{}]interface{}{
+		tagEmailKey: "alice@example.com",
+		key1:        "1",
+	})
+
+	// Registering a key again replaces its tags.
+	RegisterKey(tagEmailKey)
+	assertEqual(DeleteByTag(r, "pii"), 0)
+}
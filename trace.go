@@ -0,0 +1,135 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// TraceKey is the type of the keys TraceHandler stores values under.
+type TraceKey int
+
+// Keys under which TraceHandler stores the trace context of a request.
+const (
+	// TraceIDKey holds the trace ID, as a lowercase hex string.
+	TraceIDKey TraceKey = iota
+	// SpanIDKey holds the ID of the calling span, as a lowercase hex
+	// string.
+	SpanIDKey
+	// SampledKey holds the sampling decision, as a bool.
+	SampledKey
+)
+
+// TraceHandler wraps an http.Handler and stores the trace context of a
+// request before calling it. It's read from the W3C traceparent header,
+// or from the B3 headers, in their single or multiple header forms.
+// Invalid headers are ignored.
+func TraceHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if traceID, spanID, sampled, ok := parseTrace(r.Header); ok {
+			Set(r, TraceIDKey, traceID)
+			Set(r, SpanIDKey, spanID)
+			Set(r, SampledKey, sampled)
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// TraceID returns the trace ID stored for a given request by TraceHandler,
+// or "".
+func TraceID(r *http.Request) string {
+	id, _ := GetString(r, TraceIDKey)
+	return id
+}
+
+// SpanID returns the span ID stored for a given request by TraceHandler,
+// or "".
+func SpanID(r *http.Request) string {
+	id, _ := GetString(r, SpanIDKey)
+	return id
+}
+
+// Sampled returns the sampling decision stored for a given request by
+// TraceHandler, or false.
+func Sampled(r *http.Request) bool {
+	sampled, _ := GetBool(r, SampledKey)
+	return sampled
+}
+
+func parseTrace(h http.Header) (traceID, spanID string, sampled, ok bool) {
+	if v := h.Get("Traceparent"); v != "" {
+		return parseTraceparent(v)
+	}
+	if v := h.Get("B3"); v != "" {
+		return parseB3(v)
+	}
+	traceID = strings.ToLower(h.Get("X-B3-Traceid"))
+	spanID = strings.ToLower(h.Get("X-B3-Spanid"))
+	if !isTraceID(traceID) || !isHexID(spanID, 16) {
+		return "", "", false, false
+	}
+	s := h.Get("X-B3-Sampled")
+	sampled = s == "1" || s == "true" || h.Get("X-B3-Flags") == "1"
+	return traceID, spanID, sampled, true
+}
+
+// parseTraceparent parses a traceparent header, which is formatted as
+// version-traceid-spanid-flags.
+func parseTraceparent(v string) (traceID, spanID string, sampled, ok bool) {
+	parts := strings.Split(strings.TrimSpace(v), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" ||
+		(parts[0] == "00" && len(parts) != 4) {
+		return "", "", false, false
+	}
+	traceID, spanID = parts[1], parts[2]
+	if !isHexID(traceID, 32) || !isHexID(spanID, 16) || !isHex(parts[3], 2) {
+		return "", "", false, false
+	}
+	flags, _ := hex.DecodeString(parts[3])
+	return traceID, spanID, flags[0]&1 == 1, true
+}
+
+// parseB3 parses a b3 header, which is formatted as
+// traceid-spanid[-sampling[-parentspanid]]. A header holding only the
+// sampling decision carries no trace context.
+func parseB3(v string) (traceID, spanID string, sampled, ok bool) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(v)), "-")
+	if len(parts) < 2 || len(parts) > 4 {
+		return "", "", false, false
+	}
+	traceID, spanID = parts[0], parts[1]
+	if !isTraceID(traceID) || !isHexID(spanID, 16) {
+		return "", "", false, false
+	}
+	if len(parts) > 2 {
+		sampled = parts[2] == "1" || parts[2] == "d"
+	}
+	return traceID, spanID, sampled, true
+}
+
+// isTraceID reports whether s is a valid B3 trace ID, 64 or 128 bits.
+func isTraceID(s string) bool {
+	return isHexID(s, 16) || isHexID(s, 32)
+}
+
+// isHexID reports whether s is made of n lowercase hex digits, not all 0.
+func isHexID(s string, n int) bool {
+	return isHex(s, n) && strings.Trim(s, "0") != ""
+}
+
+// isHex reports whether s is made of n lowercase hex digits.
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,70 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTraceHandler(t *testing.T) {
+	tests := []struct {
+		headers map[string]string
+		traceID string
+		spanID  string
+		sampled bool
+	}{
+		{
+			headers: map[string]string{"Traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+			traceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+			spanID:  "00f067aa0ba902b7",
+			sampled: true,
+		},
+		{
+			headers: map[string]string{"Traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"},
+			traceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+			spanID:  "00f067aa0ba902b7",
+		},
+		{
+			headers: map[string]string{"B3": "80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-1-05e3ac9a4f6e3b90"},
+			traceID: "80f198ee56343ba864fe8b2a57d3eff7",
+			spanID:  "e457b5a2e4d86bd1",
+			sampled: true,
+		},
+		{
+			headers: map[string]string{
+				"X-B3-TraceId": "463AC35C9F6413AD",
+				"X-B3-SpanId":  "a2fb4a1d1a96d312",
+				"X-B3-Sampled": "0",
+			},
+			traceID: "463ac35c9f6413ad",
+			spanID:  "a2fb4a1d1a96d312",
+		},
+		// Invalid headers.
+		{headers: map[string]string{"Traceparent": "00-00000000000000000000000000000000-00f067aa0ba902b7-01"}},
+		{headers: map[string]string{"Traceparent": "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}},
+		{headers: map[string]string{"B3": "1"}},
+		{headers: map[string]string{}},
+	}
+
+	for i, test := range tests {
+		r := httptest.NewRequest("GET", "http://localhost:8080/", nil)
+		for k, v := range test.headers {
+			r.Header.Set(k, v)
+		}
+		var traceID, spanID string
+		var sampled bool
+		TraceHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			traceID, spanID, sampled = TraceID(r), SpanID(r), Sampled(r)
+		})).ServeHTTP(httptest.NewRecorder(), r)
+		Clear(r)
+
+		if traceID != test.traceID || spanID != test.spanID || sampled != test.sampled {
+			t.Errorf("%d: Expected (%q, %q, %v), got (%q, %q, %v).", i,
+				test.traceID, test.spanID, test.sampled, traceID, spanID, sampled)
+		}
+	}
+}
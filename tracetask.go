@@ -0,0 +1,70 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	gocontext "context"
+	"fmt"
+	"net/http"
+	"runtime/trace"
+)
+
+// traceTask is the runtime/trace task of a request, with the keys whose
+// Set calls are logged in it.
+type traceTask struct {
+	ctx  gocontext.Context
+	keys []interface{}
+}
+
+// log logs the storage of a value in the task, if key is selected. It must
+// be called without the lock held, as formatting the value may use the
+// registry.
+func (t *traceTask) log(key, val interface{}) {
+	if t == nil || !containsKey(t.keys, key) {
+		return
+	}
+	trace.Log(t.ctx, "context.Set", fmt.Sprintf("%v=%v", key, redactValue(key, val)))
+}
+
+// TraceTaskHandler wraps an http.Handler and runs it in a runtime/trace
+// task, so that go tool trace groups the work of each request. The values
+// stored with Set or SetWithTTL for the given keys during the request are
+// logged in the task, redacted for sensitive keys, see MarkSensitive.
+//
+// The task is only created when tracing is enabled at the start of the
+// request. The wrapped handler then receives a request linked to the
+// original one, see WithContext.
+func TraceTaskHandler(h http.Handler, keys ...interface{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !trace.IsEnabled() {
+			h.ServeHTTP(w, r)
+			return
+		}
+		ctx, task := trace.NewTask(r.Context(), "http.request")
+		defer task.End()
+		trace.Log(ctx, "http.request", r.Method+" "+r.URL.Path)
+
+		reg := defaultRegistry("TraceTaskHandler")
+		reg.lock()
+		original := reg.resolve(r)
+		reg.traceTasks[original] = &traceTask{ctx: ctx, keys: keys}
+		reg.unlock()
+		defer func() {
+			reg.lock()
+			delete(reg.traceTasks, original)
+			reg.unlock()
+		}()
+		h.ServeHTTP(w, WithContext(r, ctx))
+	})
+}
+
+// tracing returns the task of a given resolved request, if any. It must
+// be called with the lock held.
+func (reg *Registry) tracing(r *http.Request) *traceTask {
+	if len(reg.traceTasks) == 0 {
+		return nil
+	}
+	return reg.traceTasks[r]
+}
@@ -0,0 +1,47 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"runtime/trace"
+	"testing"
+)
+
+func TestTraceTaskHandler(t *testing.T) {
+	h := TraceTaskHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Set(r, "user", "alice")
+		Set(r, "ignored", "bob")
+	}), "user")
+
+	// Without tracing, the request is passed as is.
+	r := httptest.NewRequest("GET", "/", nil)
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	if Get(r, "user") != "alice" {
+		t.Error("Value not stored")
+	}
+	Clear(r)
+
+	var buf bytes.Buffer
+	if err := trace.Start(&buf); err != nil {
+		t.Skipf("Tracing unavailable: %v", err)
+	}
+	r = httptest.NewRequest("GET", "/traced", nil)
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	trace.Stop()
+
+	if Get(r, "user") != "alice" {
+		t.Error("Value not stored for the original request")
+	}
+	Clear(r)
+	if n := len(builtin.traceTasks); n != 0 {
+		t.Errorf("Expected no task left, got %d.", n)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("user=alice")) || bytes.Contains(buf.Bytes(), []byte("ignored=bob")) {
+		t.Error("Unexpected Set events in the trace")
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Transport is an http.RoundTripper that adds request values as headers to
+// the requests it sends, so that values such as a request ID flow to the
+// services called by a handler.
+//
+// Values are looked up for the outgoing request and then, for the keys with
+// no value, for the request bound to its context, as done by StdContext:
+//
+//	req, _ := http.NewRequestWithContext(context.StdContext(r), "GET", url, nil)
+//	resp, err := client.Do(req)
+type Transport struct {
+	// Base is the RoundTripper used to send requests. If nil,
+	// http.DefaultTransport is used.
+	Base http.RoundTripper
+	// Headers maps keys of request values to header names. Values are
+	// formatted with fmt.Sprint. Headers already set are not changed.
+	Headers map[interface{}]string
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	inbound, _ := FromStdContext(req.Context())
+	var out *http.Request
+	for key, header := range t.Headers {
+		if req.Header.Get(header) != "" {
+			continue
+		}
+		value, ok := GetOk(req, key)
+		if !ok && inbound != nil {
+			value, ok = GetOk(inbound, key)
+		}
+		if !ok {
+			continue
+		}
+		if out == nil {
+			// A RoundTripper must not modify the request.
+			out = req.Clone(req.Context())
+		}
+		out.Header.Set(header, fmt.Sprint(value))
+	}
+	if out == nil {
+		out = req
+	}
+	return base.RoundTrip(out)
+}
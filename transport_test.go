@@ -0,0 +1,53 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTransport(t *testing.T) {
+	var got http.Header
+	base := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		got = r.Header
+		return httptest.NewRecorder().Result(), nil
+	})
+	client := &http.Client{Transport: &Transport{
+		Base: base,
+		Headers: map[interface{}]string{
+			key1:     "X-Request-Id",
+			key2:     "X-Tenant",
+			"preset": "X-Preset",
+		},
+	}}
+
+	inbound := httptest.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(inbound)
+	Set(inbound, key1, "abc")
+	Set(inbound, "preset", "ignored")
+
+	req, _ := http.NewRequestWithContext(StdContext(inbound), "GET", "http://backend/", nil)
+	defer Clear(req)
+	Set(req, key2, 42)
+	req.Header.Set("X-Preset", "kept")
+
+	if _, err := client.Do(req); err != nil {
+		t.Fatal(err)
+	}
+	if v := got.Get("X-Request-Id"); v != "abc" {
+		t.Errorf("Expected X-Request-Id abc, got %q.", v)
+	}
+	if v := got.Get("X-Tenant"); v != "42" {
+		t.Errorf("Expected X-Tenant 42, got %q.", v)
+	}
+	if v := got.Get("X-Preset"); v != "kept" {
+		t.Errorf("Expected X-Preset kept, got %q.", v)
+	}
+	if req.Header.Get("X-Request-Id") != "" {
+		t.Error("Original request was modified")
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// SetWithTTL stores a value for a given key in a given request, which
+// expires after ttl. Expired values are treated as absent, and are removed
+// by Purge and by the janitor started with StartJanitor.
+//
+// Storing a value for the same key with Set removes the expiration.
+func (reg *Registry) SetWithTTL(r *http.Request, key, val interface{}, ttl time.Duration) {
+	atomic.AddUint64(&reg.counters.sets, 1)
+	reg.lock()
+	r = reg.resolve(r)
+	val, err := reg.checkSet(r, key, val)
+	if err != nil {
+		reg.unlock()
+		reg.refuse(r, err)
+		return
+	}
+	bad := reg.usedAfterClear(r)
+	reg.setUntil(r, key, val, reg.now(r).Add(ttl), "Set")
+	task := reg.tracing(r)
+	reg.unlock()
+	if bad {
+		reg.reportUseAfterClear(r, "SetWithTTL", key)
+	}
+	task.log(key, val)
+}
+
+// SetWithTTL stores a value for a given key in a given request, which
+// expires after ttl. Expired values are treated as absent, and are removed
+// by Purge and by the janitor started with StartJanitor.
+//
+// Storing a value for the same key with Set removes the expiration.
+func SetWithTTL(r *http.Request, key, val interface{}, ttl time.Duration) {
+	defaultRegistry("SetWithTTL").SetWithTTL(r, key, val, ttl)
+}
+
+// expired reports whether the value stored for a given key in a given
+// request has expired. It must be called with the lock held.
+func (reg *Registry) expired(r *http.Request, key interface{}) bool {
+	if t, ok := reg.expires[r][key]; ok {
+		return !reg.now(r).Before(t)
+	}
+	return false
+}
+
+// reapExpired removes all expired values. It must be called with the lock
+// held.
+func (reg *Registry) reapExpired() {
+	for r := range reg.expires {
+		reg.reapExpiredOf(r, reg.now(r))
+	}
+}
+
+// reapExpiredOf removes the values of a given request expired at now,
+// recording them as "Expire" in the history. It must be called with the
+// lock held.
+func (reg *Registry) reapExpiredOf(r *http.Request, now time.Time) {
+	keys, ok := reg.expires[r]
+	if !ok {
+		return
+	}
+	for key, t := range keys {
+		if !now.Before(t) {
+			reg.del(r, key, "Expire")
+		}
+	}
+	if len(keys) == 0 {
+		delete(reg.expires, r)
+	}
+	reg.publish(r)
+}
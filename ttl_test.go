@@ -0,0 +1,70 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSetWithTTL(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+
+	SetWithTTL(r, key1, "1", time.Hour)
+	SetWithTTL(r, key2, "2", -time.Second)
+	assertEqual(Get(r, key1), "1")
+
+	// Expired values are absent.
+	assertEqual(Get(r, key2), nil)
+	_, ok := GetOk(r, key2)
+	assertEqual(ok, false)
+	assertEqual(len(GetAll(r)), 1)
+	assertEqual(GetOrCompute(r, key2, func() interface{} { return "computed" }), "computed")
+
+	// Set() removes the expiration.
+	SetWithTTL(r, key2, "2", -time.Second)
+	Set(r, key2, "2")
+	assertEqual(Get(r, key2), "2")
+
+	// Purge() removes expired values but keeps the request.
+	SetWithTTL(r, key2, "2", -time.Second)
+	assertEqual(Purge(60), 0)
+	assertEqual(len(builtin.data[r]), 1)
+	assertEqual(len(builtin.expires[r]), 1)
+}
+
+func TestReapExpired(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	reg := New(WithHistory(), WithInsertionOrder())
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer reg.Clear(r)
+
+	reg.Set(r, key1, "1")
+	reg.SetWithTTL(r, key2, "2", -time.Second)
+	ch, cancel := reg.Watch(r, key2)
+	defer cancel()
+
+	// Reaping removes the value like Delete, recorded as "Expire".
+	assertEqual(reg.Purge(60), 0)
+	assertEqual(<-ch, nil)
+	assertEqual(len(reg.order[r]), 1)
+	history := reg.History(r)
+	assertEqual(len(history), 3)
+	assertEqual(history[2].Op, "Expire")
+	assertEqual(history[2].Key, key2)
+}
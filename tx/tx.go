@@ -0,0 +1,144 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package tx shares a database transaction between the handlers of a
+// request.
+//
+// Begin starts the transaction of a request, or returns the one already
+// started, and Tx retrieves it. The transaction ends when the request is
+// cleared: it's committed if the handler wrapped by Handler returned and
+// the request didn't fail, and rolled back otherwise, including when the
+// request is purged or evicted rather than cleared at its end:
+//
+//	http.Handle("/", context.ClearHandler(tx.Handler(h)))
+//
+//	func h(w http.ResponseWriter, r *http.Request) {
+//		t, err := tx.Begin(r, db)
+//		if err != nil {
+//			context.AddError(r, err)
+//			return
+//		}
+//		// ...
+//	}
+package tx
+
+import (
+	gocontext "context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/gorilla/context"
+)
+
+// beginKey is the key Begin memoizes the transaction under, and txKey the
+// key the state of the transaction is stored under.
+type (
+	beginKey struct{}
+	txKey    struct{}
+)
+
+func init() {
+	// The state belongs to the request ending the transaction.
+	context.RegisterKey(txKey{}, context.Local())
+}
+
+// state is the transaction of a request. Its flags may be set by the
+// goroutines of the request while it ends.
+type state struct {
+	tx *sql.Tx
+	// completed is set once the handler wrapped by Handler returned.
+	completed atomic.Bool
+	rollback  atomic.Bool
+}
+
+// Begin starts a transaction on db for a given request, or returns the
+// transaction already started for it, even on another database. The
+// transaction isn't bound to the request context, which is done before
+// the request is cleared.
+//
+// The transaction is ended when the request is cleared, by an OnClear
+// function: it's committed if the handler wrapped by Handler returned, and
+// rolled back if it didn't, if Rollback was called, if errors were added
+// with context.AddError, or if the status recorded by
+// context.ResponseHandler is 500 or more. Errors ending it are logged with
+// context.Logger.
+func Begin(r *http.Request, db *sql.DB) (*sql.Tx, error) {
+	v, err := context.Memoize(r, beginKey{}, func() (interface{}, error) {
+		ctx := gocontext.WithoutCancel(r.Context())
+		t, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		s := &state{tx: t}
+		context.Set(r, txKey{}, s)
+		context.OnClear(r, func() {
+			end(r, s)
+		})
+		return t, nil
+	})
+	if err != nil {
+		// Let the next call try again.
+		context.Forget(r, beginKey{})
+		return nil, err
+	}
+	return v.(*sql.Tx), nil
+}
+
+// Tx returns the transaction started by Begin for a given request, if any.
+func Tx(r *http.Request) (*sql.Tx, bool) {
+	if s, ok := context.Get(r, txKey{}).(*state); ok {
+		return s.tx, true
+	}
+	return nil, false
+}
+
+// Rollback marks the transaction of a given request to be rolled back when
+// the request is cleared.
+func Rollback(r *http.Request) {
+	if s, ok := context.Get(r, txKey{}).(*state); ok {
+		s.rollback.Store(true)
+	}
+}
+
+// Handler wraps an http.Handler and lets the transaction of its requests
+// be committed once it returns, unless the request failed. Transactions
+// of requests whose handler panicked, or that aren't served by Handler,
+// are rolled back. It must be wrapped by ClearHandler, or
+// ClearHandlerWithOptions, which ends the transaction.
+func Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r)
+		if s, ok := context.Get(r, txKey{}).(*state); ok {
+			// The values may be gone when the transaction ends.
+			if failed(r, s) {
+				s.rollback.Store(true)
+			}
+			s.completed.Store(true)
+		}
+	})
+}
+
+// failed reports whether a given request failed.
+func failed(r *http.Request, s *state) bool {
+	if s.rollback.Load() || context.HasErrors(r) {
+		return true
+	}
+	status, _ := context.Get(r, context.StatusKey).(int)
+	return status >= http.StatusInternalServerError
+}
+
+// end commits or rolls back the transaction of a given request.
+func end(r *http.Request, s *state) {
+	if !s.completed.Load() || failed(r, s) {
+		if err := s.tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			context.Logger(r).Error("tx: rollback failed", "error", err)
+		}
+		return
+	}
+	if err := s.tx.Commit(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		context.Logger(r).Error("tx: commit failed", "error", err)
+	}
+}
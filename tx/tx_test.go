@@ -0,0 +1,109 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tx
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/context"
+)
+
+// fakeDriver records the ends of the transactions of its connections.
+type fakeDriver struct {
+	mutex sync.Mutex
+	ends  []string
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) { return &fakeConn{d}, nil }
+
+func (d *fakeDriver) record(end string) {
+	d.mutex.Lock()
+	d.ends = append(d.ends, end)
+	d.mutex.Unlock()
+}
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not implemented")
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{c.d}, nil }
+
+type fakeTx struct{ d *fakeDriver }
+
+func (t fakeTx) Commit() error   { t.d.record("commit"); return nil }
+func (t fakeTx) Rollback() error { t.d.record("rollback"); return nil }
+
+var fake = &fakeDriver{}
+
+func init() {
+	sql.Register("fake", fake)
+}
+
+func TestBegin(t *testing.T) {
+	db, err := sql.Open("fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	h := context.ClearHandler(Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t1, err := Begin(r, db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t2, _ := Begin(r, db)
+		if t3, ok := Tx(r); t1 != t2 || t1 != t3 || !ok {
+			t.Error("Expected a single transaction per request")
+		}
+		switch r.URL.Path {
+		case "/error":
+			context.AddError(r, errors.New("failed"))
+		case "/rollback":
+			Rollback(r)
+		case "/panic":
+			panic("boom")
+		}
+	})))
+
+	for _, path := range []string{"/", "/error", "/rollback", "/panic"} {
+		func() {
+			defer func() { recover() }()
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+		}()
+	}
+
+	want := []string{"commit", "rollback", "rollback", "rollback"}
+	if len(fake.ends) != len(want) {
+		t.Fatalf("Expected %v, got %v.", want, fake.ends)
+	}
+	for i := range want {
+		if fake.ends[i] != want[i] {
+			t.Errorf("Request %d: expected %s, got %s.", i, want[i], fake.ends[i])
+		}
+	}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	if _, ok := Tx(r); ok {
+		t.Error("Unexpected transaction")
+	}
+
+	// Transactions of requests that aren't served to completion, here
+	// purged with their values, are rolled back.
+	if _, err := Begin(r, db); err != nil {
+		t.Fatal(err)
+	}
+	context.Purge(0)
+	if n := len(fake.ends); n != 5 || fake.ends[4] != "rollback" {
+		t.Errorf("Expected a rollback, got %v.", fake.ends)
+	}
+}
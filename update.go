@@ -0,0 +1,181 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// AppendValue appends items to the []interface{} stored for a given key in
+// a given request. A missing value, or a value that isn't an
+// []interface{}, counts as empty. The slice is copied, so slices returned
+// by earlier calls to Get are not modified.
+func (reg *Registry) AppendValue(r *http.Request, key interface{}, items ...interface{}) {
+	atomic.AddUint64(&reg.counters.sets, 1)
+	reg.lock()
+	r = reg.resolve(r)
+	if err := reg.checkWrite(r, key); err != nil {
+		reg.unlock()
+		reg.refuse(r, err)
+		return
+	}
+	s, _ := reg.data[r][key].([]interface{})
+	if reg.expired(r, key) {
+		s = nil
+	}
+	val, err := beforeSet(r, key, append(s[:len(s):len(s)], items...))
+	if err != nil {
+		reg.unlock()
+		reg.refuse(r, err)
+		return
+	}
+	reg.set(r, key, val)
+	reg.unlock()
+}
+
+// SetIfAbsent stores a value for a given key in a given request, unless a
+// value is already stored. It reports whether the value was stored.
+func (reg *Registry) SetIfAbsent(r *http.Request, key, val interface{}) bool {
+	reg.lock()
+	r = reg.resolve(r)
+	if reg.frozen[r] {
+		reg.unlock()
+		reg.refuse(r, ErrFrozen)
+		return false
+	}
+	if _, ok := reg.data[r][key]; ok && !reg.expired(r, key) {
+		reg.unlock()
+		return false
+	}
+	val, err := beforeSet(r, key, val)
+	if err != nil {
+		reg.unlock()
+		reg.refuse(r, err)
+		return false
+	}
+	atomic.AddUint64(&reg.counters.sets, 1)
+	reg.set(r, key, val)
+	reg.unlock()
+	return true
+}
+
+// Swap stores a value for a given key in a given request and returns the
+// previous value, if any. loaded reports whether a value was stored.
+func (reg *Registry) Swap(r *http.Request, key, val interface{}) (old interface{}, loaded bool) {
+	atomic.AddUint64(&reg.counters.sets, 1)
+	reg.lock()
+	r = reg.resolve(r)
+	val, err := reg.checkSet(r, key, val)
+	if err != nil {
+		reg.unlock()
+		reg.refuse(r, err)
+		return nil, false
+	}
+	defer reg.unlock()
+	old, loaded = reg.data[r][key]
+	if loaded && reg.expired(r, key) {
+		old, loaded = nil, false
+	}
+	reg.set(r, key, val)
+	return force(old), loaded
+}
+
+// Update replaces the value stored for a given key in a given request with
+// the result of fn, which receives the current value, or nil. The read and
+// the write happen atomically.
+//
+// fn runs while the registry is locked and must not use it.
+func (reg *Registry) Update(r *http.Request, key interface{}, fn func(old interface{}) interface{}) {
+	atomic.AddUint64(&reg.counters.sets, 1)
+	reg.lock()
+	r = reg.resolve(r)
+	if err := reg.checkWrite(r, key); err != nil {
+		reg.unlock()
+		reg.refuse(r, err)
+		return
+	}
+	var err error
+	func() {
+		// fn may panic.
+		defer reg.unlock()
+		var old, val interface{}
+		if !reg.expired(r, key) {
+			old = force(reg.data[r][key])
+		}
+		if val, err = beforeSet(r, key, fn(old)); err == nil {
+			reg.set(r, key, val)
+		}
+	}()
+	if err != nil {
+		reg.refuse(r, err)
+	}
+}
+
+// Add adds delta to the int64 counter stored for a given key in a given
+// request, and returns the new value. A missing value, or a value that
+// isn't an int64, counts as 0. It lets the goroutines of a request count
+// bytes, retries or queries without a lock of their own.
+func (reg *Registry) Add(r *http.Request, key interface{}, delta int64) int64 {
+	atomic.AddUint64(&reg.counters.sets, 1)
+	reg.lock()
+	r = reg.resolve(r)
+	if err := reg.checkWrite(r, key); err != nil {
+		reg.unlock()
+		reg.refuse(r, err)
+		return 0
+	}
+	n, _ := reg.data[r][key].(int64)
+	if reg.expired(r, key) {
+		n = 0
+	}
+	n += delta
+	val, err := beforeSet(r, key, n)
+	if err != nil {
+		reg.unlock()
+		reg.refuse(r, err)
+		return 0
+	}
+	reg.set(r, key, val)
+	reg.unlock()
+	return n
+}
+
+// SetIfAbsent stores a value for a given key in a given request, unless a
+// value is already stored. It reports whether the value was stored.
+func SetIfAbsent(r *http.Request, key, val interface{}) bool {
+	return defaultRegistry("SetIfAbsent").SetIfAbsent(r, key, val)
+}
+
+// Swap stores a value for a given key in a given request and returns the
+// previous value, if any. loaded reports whether a value was stored.
+func Swap(r *http.Request, key, val interface{}) (old interface{}, loaded bool) {
+	return defaultRegistry("Swap").Swap(r, key, val)
+}
+
+// Update replaces the value stored for a given key in a given request with
+// the result of fn, which receives the current value, or nil. The read and
+// the write happen atomically, so concurrent updates are not lost.
+//
+// fn runs while the context is locked and must not call other functions
+// from this package.
+func Update(r *http.Request, key interface{}, fn func(old interface{}) interface{}) {
+	defaultRegistry("Update").Update(r, key, fn)
+}
+
+// Add adds delta to the int64 counter stored for a given key in a given
+// request, and returns the new value. A missing value, or a value that
+// isn't an int64, counts as 0.
+func Add(r *http.Request, key interface{}, delta int64) int64 {
+	return defaultRegistry("Add").Add(r, key, delta)
+}
+
+// AppendValue appends items to the []interface{} stored for a given key in
+// a given request. A missing value, or a value that isn't an
+// []interface{}, counts as empty. It's meant for warnings, executed
+// queries or audit events accumulated across middleware.
+func AppendValue(r *http.Request, key interface{}, items ...interface{}) {
+	defaultRegistry("AppendValue").AppendValue(r, key, items...)
+}
@@ -0,0 +1,133 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSetIfAbsent(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+
+	assertEqual(SetIfAbsent(r, key1, "1"), true)
+	assertEqual(SetIfAbsent(r, key1, "2"), false)
+	assertEqual(Get(r, key1), "1")
+
+	// Expired values are absent.
+	SetWithTTL(r, key2, "old", -time.Second)
+	assertEqual(SetIfAbsent(r, key2, "2"), true)
+	assertEqual(Get(r, key2), "2")
+}
+
+func TestSwap(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+
+	old, loaded := Swap(r, key1, "1")
+	assertEqual(old, nil)
+	assertEqual(loaded, false)
+
+	old, loaded = Swap(r, key1, "2")
+	assertEqual(old, "1")
+	assertEqual(loaded, true)
+	assertEqual(Get(r, key1), "2")
+}
+
+func TestUpdate(t *testing.T) {
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			Update(r, key1, func(old interface{}) interface{} {
+				n, _ := old.(int)
+				return n + 1
+			})
+		}()
+	}
+	wg.Wait()
+
+	if v := Get(r, key1); v != 100 {
+		t.Errorf("Expected 100, got %v.", v)
+	}
+}
+
+func TestAdd(t *testing.T) {
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			Add(r, key1, 2)
+		}()
+	}
+	wg.Wait()
+
+	if v := Get(r, key1); v != int64(200) {
+		t.Errorf("Expected 200, got %v.", v)
+	}
+	if n := Add(r, key1, -50); n != 150 {
+		t.Errorf("Expected 150, got %d.", n)
+	}
+	Set(r, key2, "not a counter")
+	if n := Add(r, key2, 1); n != 1 {
+		t.Errorf("Expected 1, got %d.", n)
+	}
+}
+
+func TestAppendValue(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if !reflect.DeepEqual(val, exp) {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+
+	AppendValue(r, key1, "a")
+	first := Get(r, key1)
+	AppendValue(r, key1, "b", "c")
+	assertEqual(Get(r, key1), []interface{}{"a", "b", "c"})
+	assertEqual(first, []interface{}{"a"})
+
+	Set(r, key2, "not a slice")
+	AppendValue(r, key2, 1)
+	assertEqual(Get(r, key2), []interface{}{1})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			AppendValue(r, "events", i)
+		}()
+	}
+	wg.Wait()
+	assertEqual(len(Get(r, "events").([]interface{})), 100)
+}
@@ -0,0 +1,85 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+)
+
+// InvalidValue is the error of the writes refused by a validator
+// registered with RegisterValidator.
+type InvalidValue struct {
+	Key   interface{}
+	Value interface{}
+	// Err is the error returned by the validator.
+	Err error
+}
+
+// Error implements the error interface. The value is redacted for
+// sensitive keys, see MarkSensitive.
+func (e *InvalidValue) Error() string {
+	return fmt.Sprintf("context: invalid value %#v for key %v: %v", redactValue(e.Key, e.Value), e.Key, e.Err)
+}
+
+// Unwrap returns the error returned by the validator.
+func (e *InvalidValue) Unwrap() error {
+	return e.Err
+}
+
+// RegisterValidator registers a function checking the values stored for a
+// key, such as their type or that an ID isn't empty, so that malformed
+// values are refused when they're stored rather than failing far away,
+// when they're read. Writes of values for which fn returns an error are
+// refused with an *InvalidValue wrapping it, like the writes refused by an
+// interceptor, see Interceptor.BeforeSet, which also covers the values of
+// GetOrCompute, SetLazy and SetFuture. Use SetChecked to get the error
+// instead.
+//
+// fn may run while the registry is locked and must not use it. Validators
+// apply to all registries.
+func RegisterValidator(key interface{}, fn func(val interface{}) error) {
+	RegisterInterceptor(key, Interceptor{
+		BeforeSet: func(r *http.Request, key, val interface{}) (interface{}, error) {
+			if err := fn(val); err != nil {
+				return nil, &InvalidValue{Key: key, Value: val, Err: err}
+			}
+			return val, nil
+		},
+	})
+}
+
+// SetChecked stores a value for a given key in a given request, like Set,
+// but returns the error of a refused write instead of handling it with
+// HandleRefusedWrites: ErrFrozen, an error wrapping ErrImmutable, an
+// *InvalidValue, or the error of an interceptor.
+func (reg *Registry) SetChecked(r *http.Request, key, val interface{}) error {
+	atomic.AddUint64(&reg.counters.sets, 1)
+	reg.lock()
+	r = reg.resolve(r)
+	val, err := reg.checkSet(r, key, val)
+	if err != nil {
+		reg.unlock()
+		return err
+	}
+	bad := reg.usedAfterClear(r)
+	reg.set(r, key, val)
+	task := reg.tracing(r)
+	reg.unlock()
+	if bad {
+		reg.reportUseAfterClear(r, "SetChecked", key)
+	}
+	task.log(key, val)
+	return nil
+}
+
+// SetChecked stores a value for a given key in a given request, like Set,
+// but returns the error of a refused write instead of handling it with
+// HandleRefusedWrites: ErrFrozen, an error wrapping ErrImmutable, an
+// *InvalidValue, or the error of an interceptor.
+func SetChecked(r *http.Request, key, val interface{}) error {
+	return defaultRegistry("SetChecked").SetChecked(r, key, val)
+}
@@ -0,0 +1,103 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+type validatedKey int
+
+const (
+	accountIDKey validatedKey = iota
+	retriesKey
+)
+
+func TestRegisterValidator(t *testing.T) {
+	errEmptyID := errors.New("empty account ID")
+	RegisterValidator(accountIDKey, func(val interface{}) error {
+		id, ok := val.(string)
+		if !ok {
+			return errors.New("account ID must be a string")
+		}
+		if id == "" {
+			return errEmptyID
+		}
+		return nil
+	})
+
+	reg := New()
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer reg.Clear(r)
+
+	if err := reg.SetChecked(r, accountIDKey, "42"); err != nil {
+		t.Errorf("Unexpected error %v.", err)
+	}
+	err := reg.SetChecked(r, accountIDKey, "")
+	var invalid *InvalidValue
+	if !errors.As(err, &invalid) || !errors.Is(err, errEmptyID) {
+		t.Fatalf("Expected an InvalidValue wrapping errEmptyID, got %v.", err)
+	}
+	if s := err.Error(); s != `context: invalid value "" for key 0: empty account ID` {
+		t.Errorf("Unexpected message %q.", s)
+	}
+	if v := reg.Get(r, accountIDKey); v != "42" {
+		t.Errorf("Expected 42, got %v.", v)
+	}
+
+	defer func() {
+		if err, _ := recover().(error); !errors.As(err, &invalid) {
+			t.Errorf("Expected an InvalidValue panic, got %v.", err)
+		}
+	}()
+	reg.Set(r, accountIDKey, 42)
+}
+
+func TestValidatorComputedValues(t *testing.T) {
+	errNegative := errors.New("negative")
+	RegisterValidator(retriesKey, func(val interface{}) error {
+		if n, _ := val.(int); n < 0 {
+			return errNegative
+		}
+		return nil
+	})
+
+	var refused []error
+	reg := New(WithRefusedWrites(func(r *http.Request, err error) {
+		refused = append(refused, err)
+	}))
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer reg.Clear(r)
+
+	// Refused results of GetOrCompute aren't stored.
+	if v := reg.GetOrCompute(r, retriesKey, func() interface{} { return -1 }); v != nil {
+		t.Errorf("Expected nil, got %v.", v)
+	}
+	if _, ok := reg.GetOk(r, retriesKey); ok {
+		t.Error("Refused value stored")
+	}
+
+	// Refused values don't resolve futures.
+	resolve := reg.SetFuture(r, retriesKey)
+	resolve(-1)
+	resolve(1)
+	if v := reg.Get(r, retriesKey); v != 1 {
+		t.Errorf("Expected 1, got %v.", v)
+	}
+	if len(refused) != 2 || !errors.Is(refused[0], errNegative) || !errors.Is(refused[1], errNegative) {
+		t.Errorf("Unexpected refused writes %v.", refused)
+	}
+
+	// Refused lazy values panic when they're read.
+	reg.SetLazy(r, retriesKey, func() interface{} { return -1 })
+	defer func() {
+		if err, _ := recover().(error); !errors.Is(err, errNegative) {
+			t.Errorf("Expected an errNegative panic, got %v.", err)
+		}
+	}()
+	reg.Get(r, retriesKey)
+}
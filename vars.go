@@ -0,0 +1,44 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+)
+
+// Keys under which routers store the route matched for a request.
+var (
+	varsKey  = NewKey[map[string]string]("vars")
+	routeKey = NewKey[interface{}]("route")
+)
+
+// SetVars stores the route variables matched for a given request, such as
+// path parameters. Routers call it before handing the request to the
+// matched handler so that handlers can retrieve them with Vars.
+//
+// The map is stored as is and must not be modified afterwards.
+func SetVars(r *http.Request, vars map[string]string) {
+	varsKey.Set(r, vars)
+}
+
+// Vars returns the route variables stored for a given request by SetVars,
+// or nil.
+func Vars(r *http.Request) map[string]string {
+	vars, _ := varsKey.Get(r)
+	return vars
+}
+
+// SetCurrentRoute stores the route matched for a given request. Its type is
+// up to the router.
+func SetCurrentRoute(r *http.Request, route interface{}) {
+	routeKey.Set(r, route)
+}
+
+// CurrentRoute returns the route stored for a given request by
+// SetCurrentRoute, or nil.
+func CurrentRoute(r *http.Request) interface{} {
+	route, _ := routeKey.Get(r)
+	return route
+}
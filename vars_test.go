@@ -0,0 +1,38 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestVars(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if !reflect.DeepEqual(val, exp) {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	r, _ := http.NewRequest("GET", "http://localhost:8080/users/42", nil)
+	defer Clear(r)
+
+	assertEqual(Vars(r), map[string]string(nil))
+	assertEqual(CurrentRoute(r), nil)
+
+	type route struct{ name string }
+	SetVars(r, map[string]string{"id": "42"})
+	SetCurrentRoute(r, &route{"user"})
+
+	assertEqual(Vars(r), map[string]string{"id": "42"})
+	assertEqual(CurrentRoute(r), &route{"user"})
+
+	// Values follow links, like any other value.
+	clone := r.Clone(r.Context())
+	Link(r, clone)
+	defer Clear(clone)
+	assertEqual(Vars(clone)["id"], "42")
+}
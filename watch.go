@@ -0,0 +1,118 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+)
+
+// watcher is a channel returned by Watch.
+type watcher struct {
+	ch     chan interface{}
+	closed bool
+	// r is the request watched, changed by Link when it moves the
+	// watchers of a clone.
+	r *http.Request
+}
+
+// send delivers val, replacing the previous notification if it wasn't
+// received yet so that notifications never block. It must be called with
+// the lock held.
+func (w *watcher) send(val interface{}) {
+	for {
+		select {
+		case w.ch <- val:
+			return
+		default:
+		}
+		select {
+		case <-w.ch:
+		default:
+		}
+	}
+}
+
+// Watch returns a channel receiving the values stored for a given key in a
+// given request, or nil when the value is deleted. The channel only holds
+// the latest notification: a watcher that falls behind misses intermediate
+// values. Values stored with SetLazy or SetFuture are notified as nil, so
+// that they aren't computed; use Get to read them.
+//
+// The channel is closed when cancel is called or when the request is
+// cleared.
+func (reg *Registry) Watch(r *http.Request, key interface{}) (<-chan interface{}, func()) {
+	w := &watcher{ch: make(chan interface{}, 1)}
+	reg.lock()
+	r = reg.resolve(r)
+	if !reg.register(r) {
+		reg.unlock()
+		close(w.ch)
+		return w.ch, func() {}
+	}
+	if reg.watchers[r] == nil {
+		reg.watchers[r] = make(map[interface{}][]*watcher)
+	}
+	w.r = r
+	reg.watchers[r][key] = append(reg.watchers[r][key], w)
+	reg.unlock()
+
+	cancel := func() {
+		reg.lock()
+		defer reg.unlock()
+		if w.closed {
+			return
+		}
+		ws := reg.watchers[w.r][key]
+		for i := range ws {
+			if ws[i] == w {
+				reg.watchers[w.r][key] = append(ws[:i:i], ws[i+1:]...)
+				break
+			}
+		}
+		w.closed = true
+		close(w.ch)
+	}
+	return w.ch, cancel
+}
+
+// notify sends val to the watchers of a given key in a given request. It
+// must be called with the lock held.
+func (reg *Registry) notify(r *http.Request, key, val interface{}) {
+	ws := reg.watchers[r][key]
+	if len(ws) == 0 {
+		return
+	}
+	switch val.(type) {
+	case *lazy, *future:
+		val = nil
+	}
+	for _, w := range ws {
+		w.send(val)
+	}
+}
+
+// closeWatchers closes the channels of all the watchers of a given
+// request. It must be called with the lock held.
+func (reg *Registry) closeWatchers(r *http.Request) {
+	for _, ws := range reg.watchers[r] {
+		for _, w := range ws {
+			w.closed = true
+			close(w.ch)
+		}
+	}
+	delete(reg.watchers, r)
+}
+
+// Watch returns a channel receiving the values stored for a given key in a
+// given request, or nil when the value is deleted. The channel only holds
+// the latest notification: a watcher that falls behind misses intermediate
+// values. Values stored with SetLazy or SetFuture are notified as nil, so
+// that they aren't computed; use Get to read them.
+//
+// The channel is closed when cancel is called or when the request is
+// cleared.
+func Watch(r *http.Request, key interface{}) (<-chan interface{}, func()) {
+	return defaultRegistry("Watch").Watch(r, key)
+}
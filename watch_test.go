@@ -0,0 +1,49 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestWatch(t *testing.T) {
+	assertEqual := func(val interface{}, exp interface{}) {
+		if val != exp {
+			t.Errorf("Expected %v, got %v.", exp, val)
+		}
+	}
+
+	r, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	defer Clear(r)
+
+	ch, cancel := Watch(r, key1)
+	other, cancelOther := Watch(r, key1)
+
+	Set(r, key1, "1")
+	assertEqual(<-ch, "1")
+	Set(r, key2, "ignored")
+	Delete(r, key1)
+	assertEqual(<-ch, nil)
+
+	// Only the latest notification is kept.
+	Set(r, key1, "2")
+	Set(r, key1, "3")
+	assertEqual(<-ch, "3")
+	Handle(r).Set(key1, "4")
+	assertEqual(<-ch, "4")
+
+	cancel()
+	cancel()
+	_, ok := <-ch
+	assertEqual(ok, false)
+	assertEqual(<-other, "4")
+
+	// Clear closes the remaining channels.
+	Clear(r)
+	_, ok = <-other
+	assertEqual(ok, false)
+	cancelOther()
+}
@@ -0,0 +1,12 @@
+module github.com/gorilla/context/zapfields
+
+go 1.22
+
+require (
+	github.com/gorilla/context v0.0.0-00010101000000-000000000000
+	go.uber.org/zap v1.28.0
+)
+
+require go.uber.org/multierr v1.10.0 // indirect
+
+replace github.com/gorilla/context => ../
@@ -0,0 +1,69 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package zapfields turns request values into go.uber.org/zap fields.
+//
+// An Adapter maps keys to field names, and stamps request values on log
+// entries:
+//
+//	fields := zapfields.New(map[interface{}]string{
+//		context.RequestIDKey: "request_id",
+//		userKey:              "user",
+//	})
+//
+//	func h(w http.ResponseWriter, r *http.Request) {
+//		logger := logger.With(fields.Fields(r)...)
+//		// ...
+//	}
+package zapfields
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/context"
+	"go.uber.org/zap"
+)
+
+// Adapter turns the values stored for a set of keys into zap fields.
+type Adapter struct {
+	fields []mapped
+}
+
+// mapped is a field name and the key of its value.
+type mapped struct {
+	key  interface{}
+	name string
+}
+
+// New returns an Adapter turning the values stored for the keys of mapping
+// into the fields they map to.
+func New(mapping map[interface{}]string) *Adapter {
+	fields := make([]mapped, 0, len(mapping))
+	for k, name := range mapping {
+		fields = append(fields, mapped{key: k, name: name})
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].name < fields[j].name
+	})
+	return &Adapter{fields: fields}
+}
+
+// Fields returns the fields for the values stored for a given request,
+// sorted by name. Keys with no value are skipped, and the values of
+// sensitive keys are redacted, see context.MarkSensitive.
+func (a *Adapter) Fields(r *http.Request) []zap.Field {
+	fields := make([]zap.Field, 0, len(a.fields))
+	for _, f := range a.fields {
+		value, ok := context.GetOk(r, f.key)
+		if !ok {
+			continue
+		}
+		if context.IsSensitive(f.key) {
+			value = context.Redacted
+		}
+		fields = append(fields, zap.Any(f.name, value))
+	}
+	return fields
+}
@@ -0,0 +1,52 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapfields
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/context"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type keyType int
+
+const secretKey keyType = 0
+
+func TestFields(t *testing.T) {
+	context.MarkSensitive(secretKey)
+	a := New(map[interface{}]string{
+		"user":    "user",
+		"retries": "retries",
+		"missing": "missing",
+		secretKey: "secret",
+	})
+
+	r := httptest.NewRequest("GET", "/", nil)
+	defer context.Clear(r)
+	context.Set(r, "user", "alice")
+	context.Set(r, "retries", 2)
+	context.Set(r, secretKey, "hidden")
+
+	core, logs := observer.New(zap.InfoLevel)
+	zap.New(core).Info("serving", a.Fields(r)...)
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d.", len(entries))
+	}
+	got := entries[0].ContextMap()
+	want := map[string]interface{}{"retries": int64(2), "secret": context.Redacted, "user": "alice"}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v.", want, got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("Field %s: expected %v, got %v.", k, v, got[k])
+		}
+	}
+}
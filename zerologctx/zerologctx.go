@@ -0,0 +1,93 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package zerologctx adds request values to github.com/rs/zerolog logs.
+//
+// An Adapter maps keys to field names. It enriches loggers derived for a
+// request, or, as a hook, the events logged with a context bound to the
+// request by context.StdContext:
+//
+//	fields := zerologctx.New(map[interface{}]string{
+//		context.RequestIDKey: "request_id",
+//		userKey:              "user",
+//	})
+//
+//	func h(w http.ResponseWriter, r *http.Request) {
+//		logger := fields.With(r, log.With()).Logger()
+//		// ...
+//	}
+package zerologctx
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/context"
+	"github.com/rs/zerolog"
+)
+
+// Adapter adds the values stored for a set of keys to zerolog loggers and
+// events.
+type Adapter struct {
+	fields []mapped
+}
+
+// mapped is a field name and the key of its value.
+type mapped struct {
+	key  interface{}
+	name string
+}
+
+// New returns an Adapter adding the values stored for the keys of mapping
+// as the fields they map to. Fields are added sorted by name; keys with no
+// value are skipped, and the values of sensitive keys are redacted, see
+// context.MarkSensitive.
+func New(mapping map[interface{}]string) *Adapter {
+	fields := make([]mapped, 0, len(mapping))
+	for k, name := range mapping {
+		fields = append(fields, mapped{key: k, name: name})
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].name < fields[j].name
+	})
+	return &Adapter{fields: fields}
+}
+
+// each calls fn for the fields of the values stored for a given request.
+func (a *Adapter) each(r *http.Request, fn func(name string, value interface{})) {
+	for _, f := range a.fields {
+		value, ok := context.GetOk(r, f.key)
+		if !ok {
+			continue
+		}
+		if context.IsSensitive(f.key) {
+			value = context.Redacted
+		}
+		fn(f.name, value)
+	}
+}
+
+// With adds the fields for the values stored for a given request to a
+// logger context, and returns it.
+func (a *Adapter) With(r *http.Request, c zerolog.Context) zerolog.Context {
+	a.each(r, func(name string, value interface{}) {
+		c = c.Interface(name, value)
+	})
+	return c
+}
+
+// Hook returns a zerolog.Hook adding the fields to the events logged with
+// a context returned by context.StdContext, or derived from one, as set by
+// zerolog.Event.Ctx.
+func (a *Adapter) Hook() zerolog.Hook {
+	return zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, msg string) {
+		r, ok := context.FromStdContext(e.GetCtx())
+		if !ok {
+			return
+		}
+		a.each(r, func(name string, value interface{}) {
+			e.Interface(name, value)
+		})
+	})
+}
@@ -0,0 +1,58 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zerologctx
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/context"
+	"github.com/rs/zerolog"
+)
+
+type keyType int
+
+const secretKey keyType = 0
+
+func TestAdapter(t *testing.T) {
+	context.MarkSensitive(secretKey)
+	a := New(map[interface{}]string{
+		"user":    "user",
+		"retries": "retries",
+		"missing": "missing",
+		secretKey: "secret",
+	})
+
+	r := httptest.NewRequest("GET", "/", nil)
+	defer context.Clear(r)
+	context.Set(r, "user", "alice")
+	context.Set(r, "retries", 2)
+	context.Set(r, secretKey, "hidden")
+
+	var buf bytes.Buffer
+	base := zerolog.New(&buf)
+	logger := a.With(r, base.With()).Logger()
+	logger.Info().Msg("with")
+	hooked := base.Hook(a.Hook())
+	hooked.Info().Ctx(context.StdContext(r)).Msg("hook")
+	hooked.Info().Msg("unbound")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := []string{
+		`{"level":"info","retries":2,"secret":"[REDACTED]","user":"alice","message":"with"}`,
+		`{"level":"info","retries":2,"secret":"[REDACTED]","user":"alice","message":"hook"}`,
+		`{"level":"info","message":"unbound"}`,
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("Unexpected output:\n%s", buf.String())
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("Expected %s, got %s", want[i], lines[i])
+		}
+	}
+}